@@ -1,13 +1,18 @@
 package chart
 
 import (
+	"fmt"
 	mtx "github.com/gonum/matrix/mat64"
 	"github.com/gonum/plot"
+	"github.com/gonum/plot/palette"
 	"github.com/gonum/plot/plotter"
 	"github.com/gonum/plot/plotutil"
 	"github.com/gonum/plot/vg"
 	"github.com/gonum/plot/vg/draw"
+	"github.com/kevinschoon/fit/types"
 	"image/color"
+	"math"
+	"sort"
 )
 
 type Config struct {
@@ -21,6 +26,236 @@ type Config struct {
 	Width          vg.Length
 	Height         vg.Length
 	PlotTime       bool
+	Precision      types.Precision   // Precision of time values on the X axis
+	Overlays       []Overlay         // Functions drawn over a line chart
+	Dashed         map[string]string // Columns drawn as dashed lines such as forecasts, keyed to the column they extend
+	Markers        []Marker          // Points marked on a line chart such as anomalies
+	Group          string            // Column of labels coloring the points of a scatter chart
+	LogX           bool              // Logarithmic X axis
+	LogY           bool              // Logarithmic Y axis
+}
+
+func (cfg Config) dashed(column string) bool {
+	_, ok := cfg.Dashed[column]
+	return ok
+}
+
+// colors returns the index of the plotutil.Color of
+// each line in the order plotutil.AddLines assigns
+// them to solid lines. Dashed lines take the color
+// of the line they extend.
+func (cfg Config) colors(names []string) map[string]int {
+	colors := make(map[string]int)
+	for _, name := range names {
+		if !cfg.dashed(name) && !cfg.flag(name) {
+			colors[name] = len(colors)
+		}
+	}
+	next := len(colors)
+	for _, name := range names {
+		if !cfg.dashed(name) {
+			continue
+		}
+		if color, ok := colors[cfg.Dashed[name]]; ok && !cfg.dashed(cfg.Dashed[name]) {
+			colors[name] = color
+			continue
+		}
+		colors[name] = next
+		next++
+	}
+	return colors
+}
+
+func (cfg Config) flag(column string) bool {
+	for _, marker := range cfg.Markers {
+		if marker.Flag == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Marker marks the points of Column on
+// each row where the Flag column is 1
+type Marker struct {
+	Column string
+	Flag   string
+}
+
+// GetMarkers returns the points of a Marker
+func GetMarkers(mx *mtx.Dense, columns []string, marker Marker) plotter.XYs {
+	xys := make(plotter.XYs, 0)
+	column, flag := -1, -1
+	for i, name := range columns {
+		switch name {
+		case marker.Column:
+			column = i
+		case marker.Flag:
+			flag = i
+		}
+	}
+	if column < 0 || flag < 0 {
+		return xys
+	}
+	r, _ := mx.Dims()
+	for j := 0; j < r; j++ {
+		x, y := mx.At(j, 0), mx.At(j, column)
+		if mx.At(j, flag) != 1 || math.IsNaN(x) || math.IsNaN(y) {
+			continue
+		}
+		xys = append(xys, struct{ X, Y float64 }{x, y})
+	}
+	return xys
+}
+
+// GetScatters returns variadic arguments for
+// plotutil.AddScatters with the first column as
+// the X axis. When group names a column the points
+// of each other column are split by its values.
+func GetScatters(mx *mtx.Dense, columns []string, group string) []interface{} {
+	r, c := mx.Dims()
+	position := -1
+	for i, name := range columns {
+		if i > 0 && name == group {
+			position = i
+		}
+	}
+	if position < 0 {
+		return GetLines(mx, columns)
+	}
+	labels := make([]float64, 0)
+	seen := make(map[float64]bool)
+	for j := 0; j < r; j++ {
+		if label := mx.At(j, position); !math.IsNaN(label) && !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	sort.Float64s(labels)
+	data := make([]interface{}, 0)
+	for i := 1; i < c; i++ {
+		if i == position {
+			continue
+		}
+		for _, label := range labels {
+			xys := make(plotter.XYs, 0, r)
+			for j := 0; j < r; j++ {
+				x, y := mx.At(j, 0), mx.At(j, i)
+				if mx.At(j, position) != label || math.IsNaN(x) || math.IsNaN(y) {
+					continue
+				}
+				xys = append(xys, struct{ X, Y float64 }{x, y})
+			}
+			data = append(data, fmt.Sprintf("%s %s %g", columns[i], group, label), xys)
+		}
+	}
+	return data
+}
+
+// GetHistogram returns a histogram of the bins
+// in the columns bin_start, bin_end and count
+// (or density) of a binned query
+//
+//	bin_start,bin_end,count
+//	0,10,3
+//	10,20,5
+func GetHistogram(mx *mtx.Dense) (*plotter.Histogram, error) {
+	r, c := mx.Dims()
+	if c < 3 {
+		return nil, fmt.Errorf("Histogram requires bin_start, bin_end and count columns")
+	}
+	hist := &plotter.Histogram{Bins: make([]plotter.HistogramBin, 0, r)}
+	for i := 0; i < r; i++ {
+		bin := plotter.HistogramBin{Min: mx.At(i, 0), Max: mx.At(i, 1), Weight: mx.At(i, 2)}
+		if math.IsNaN(bin.Min) || math.IsNaN(bin.Max) || math.IsNaN(bin.Weight) {
+			continue
+		}
+		hist.Bins = append(hist.Bins, bin)
+	}
+	if len(hist.Bins) > 0 {
+		hist.Width = hist.Bins[0].Max - hist.Bins[0].Min
+	}
+	return hist, nil
+}
+
+// Grid is a plotter.GridXYZ of a matrix such as a
+// correlation query where the first column holds
+// the position of the column each row corresponds
+// to. Cells are drawn at the position of their
+// column on the X axis and of their row on the Y
+// axis.
+//
+//	column,x,y
+//	1,1,0.5
+//	2,0.5,1
+type Grid struct {
+	mx *mtx.Dense
+}
+
+func (g Grid) Dims() (int, int) {
+	r, c := g.mx.Dims()
+	return c - 1, r
+}
+
+func (g Grid) Z(c, r int) float64 { return g.mx.At(r, c+1) }
+func (g Grid) X(c int) float64    { return float64(c + 1) }
+func (g Grid) Y(r int) float64    { return g.mx.At(r, 0) }
+
+// GetGrid returns a Grid of the matrix
+func GetGrid(mx *mtx.Dense) (*Grid, error) {
+	r, c := mx.Dims()
+	if r == 0 || c < 2 {
+		return nil, fmt.Errorf("Heatmap requires a column of positions and at least one column of values")
+	}
+	return &Grid{mx: mx}, nil
+}
+
+// ColumnTicks labels the position of each
+// column after the first with its name
+func ColumnTicks(columns []string) plot.ConstantTicks {
+	ticks := make(plot.ConstantTicks, 0, len(columns))
+	for i := 1; i < len(columns); i++ {
+		ticks = append(ticks, plot.Tick{Value: float64(i), Label: columns[i]})
+	}
+	return ticks
+}
+
+// positive removes points which cannot
+// be drawn on a logarithmic axis
+func positive(xys plotter.XYs, x, y bool) plotter.XYs {
+	result := make(plotter.XYs, 0, len(xys))
+	for _, xy := range xys {
+		if (x && xy.X <= 0) || (y && xy.Y <= 0) {
+			continue
+		}
+		result = append(result, xy)
+	}
+	return result
+}
+
+// Overlay is a function of X such as a
+// fitted curve drawn as a dashed line
+type Overlay struct {
+	Name string
+	Func func(x float64) float64
+}
+
+// TimeTicks formats ticks as times stored
+// at the configured Precision
+type TimeTicks struct {
+	Precision types.Precision
+	Format    string
+}
+
+func (t TimeTicks) Ticks(min, max float64) []plot.Tick {
+	ticks := plot.DefaultTicks{}.Ticks(min, max)
+	for i := range ticks {
+		if ticks[i].Label == "" { // Minor ticks have no label
+			continue
+		}
+		ticks[i].Label = t.Precision.Time(ticks[i].Value).Format(t.Format)
+	}
+	return ticks
 }
 
 type Vector struct {
@@ -58,7 +293,15 @@ func getPlot(cfg Config) (*plot.Plot, error) {
 	plt.X.Tick.Label.Font.Size = 0.2 * vg.Inch
 
 	if cfg.PlotTime {
-		plt.X.Tick.Marker = plot.UnixTimeTicks{Format: "2006-01-02"}
+		plt.X.Tick.Marker = TimeTicks{Precision: cfg.Precision, Format: "2006-01-02"}
+	}
+	if cfg.LogX {
+		plt.X.Scale = plot.LogScale{}
+		plt.X.Tick.Marker = plot.LogTicks{}
+	}
+	if cfg.LogY {
+		plt.Y.Scale = plot.LogScale{}
+		plt.Y.Tick.Marker = plot.LogTicks{}
 	}
 	return plt, nil
 }
@@ -74,15 +317,20 @@ func getPlot(cfg Config) (*plot.Plot, error) {
 //
 // Line Y: 1,1 2,3 3,5
 // Line Z: 1,2 2,4 3,6
+//
+// Points missing either value are skipped.
 
 func GetLines(mx *mtx.Dense, columns []string) []interface{} {
 	data := make([]interface{}, 0)
 	r, c := mx.Dims()
 	for i := 1; i < c; i++ {
-		xys := make(plotter.XYs, r)
+		xys := make(plotter.XYs, 0, r)
 		for j := 0; j < r; j++ {
-			xys[j].X = mx.At(j, 0)
-			xys[j].Y = mx.At(j, i)
+			x, y := mx.At(j, 0), mx.At(j, i)
+			if math.IsNaN(x) || math.IsNaN(y) {
+				continue
+			}
+			xys = append(xys, struct{ X, Y float64 }{x, y})
 		}
 		data = append(data, columns[i])
 		data = append(data, xys)
@@ -91,12 +339,18 @@ func GetLines(mx *mtx.Dense, columns []string) []interface{} {
 }
 
 // GetValues returns an array of plotter.Values
-// where each entry is a column vector.
+// where each entry is a column vector without
+// missing values.
 func GetValues(mx *mtx.Dense) []plotter.Values {
-	_, c := mx.Dims()
+	r, c := mx.Dims()
 	values := make([]plotter.Values, c)
 	for i := 0; i < c; i++ {
-		values[i] = plotter.Values(mtx.Col(nil, i, mx))
+		values[i] = make(plotter.Values, 0, r)
+		for j := 0; j < r; j++ {
+			if value := mx.At(j, i); !math.IsNaN(value) {
+				values[i] = append(values[i], value)
+			}
+		}
 	}
 	return values
 }
@@ -119,10 +373,83 @@ func New(cfg Config, mx *mtx.Dense) (vg.CanvasWriterTo, error) {
 			box.MedianStyle.Color = cfg.PrimaryColor
 			plt.Add(box)
 		}
+	case "scatter":
+		scatters := GetScatters(mx, cfg.Columns, cfg.Group)
+		for i := 0; i < len(scatters); i += 2 {
+			scatters[i+1] = positive(scatters[i+1].(plotter.XYs), cfg.LogX, cfg.LogY)
+		}
+		if err := plotutil.AddScatters(plt, scatters...); err != nil {
+			return nil, err
+		}
+	case "histogram":
+		hist, err := GetHistogram(mx)
+		if err != nil {
+			return nil, err
+		}
+		hist.FillColor = cfg.PrimaryColor
+		hist.LineStyle = plotter.DefaultLineStyle
+		hist.LineStyle.Color = cfg.SecondaryColor
+		plt.Add(hist)
+	case "heatmap":
+		grid, err := GetGrid(mx)
+		if err != nil {
+			return nil, err
+		}
+		plt.Add(plotter.NewHeatMap(grid, palette.Heat(12, 1)))
+		plt.X.Tick.Marker = ColumnTicks(cfg.Columns)
+		plt.Y.Tick.Marker = ColumnTicks(cfg.Columns)
 	default: // Default to line chart
-		if err := plotutil.AddLines(plt, GetLines(mx, cfg.Columns)...); err != nil {
+		lines := GetLines(mx, cfg.Columns)
+		names := make([]string, 0, len(lines)/2)
+		for i := 0; i < len(lines); i += 2 {
+			names = append(names, lines[i].(string))
+		}
+		colors := cfg.colors(names)
+		solid := make([]interface{}, 0, len(lines))
+		for i := 0; i < len(lines); i += 2 {
+			lines[i+1] = positive(lines[i+1].(plotter.XYs), cfg.LogX, cfg.LogY)
+			name := lines[i].(string)
+			if cfg.flag(name) { // Flags are drawn as markers
+				continue
+			}
+			if !cfg.dashed(name) {
+				solid = append(solid, lines[i], lines[i+1])
+				continue
+			}
+			line, err := plotter.NewLine(lines[i+1].(plotter.XYs))
+			if err != nil {
+				return nil, err
+			}
+			line.Color = plotutil.Color(colors[name])
+			line.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+			plt.Add(line)
+			plt.Legend.Add(name, line)
+		}
+		if err := plotutil.AddLines(plt, solid...); err != nil {
 			return nil, err
 		}
+		for i, overlay := range cfg.Overlays {
+			fn := plotter.NewFunction(overlay.Func)
+			fn.Color = plotutil.Color(i)
+			fn.Dashes = []vg.Length{vg.Points(6), vg.Points(3)}
+			plt.Add(fn)
+			plt.Legend.Add(overlay.Name, fn)
+		}
+		for _, marker := range cfg.Markers {
+			xys := positive(GetMarkers(mx, cfg.Columns, marker), cfg.LogX, cfg.LogY)
+			if xys.Len() == 0 {
+				continue
+			}
+			scatter, err := plotter.NewScatter(xys)
+			if err != nil {
+				return nil, err
+			}
+			scatter.Color = color.RGBA{R: 220, A: 255}
+			scatter.Radius = vg.Points(4)
+			scatter.Shape = draw.RingGlyph{}
+			plt.Add(scatter)
+			plt.Legend.Add(marker.Flag, scatter)
+		}
 	}
 	plt.Add(plotter.NewGrid())
 	canvas, err := draw.NewFormattedCanvas(cfg.Width, cfg.Height, "png")
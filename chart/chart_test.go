@@ -2,8 +2,12 @@ package chart
 
 import (
 	mtx "github.com/gonum/matrix/mat64"
+	"github.com/gonum/plot"
 	"github.com/gonum/plot/plotter"
+	"github.com/gonum/plot/vg"
+	"github.com/kevinschoon/fit/types"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
 )
 
@@ -46,3 +50,123 @@ func TestGetLines(t *testing.T) {
 	assert.Equal(t, 3.0, x)
 	assert.Equal(t, 6.0, y)
 }
+
+func TestTimeTicks(t *testing.T) {
+	ticks := TimeTicks{Precision: types.Milliseconds, Format: "2006-01-02"}.Ticks(978307200000, 978739200000)
+	assert.NotEmpty(t, ticks)
+	for _, tick := range ticks {
+		if tick.Label != "" {
+			assert.Regexp(t, `^2001-01-0\d$`, tick.Label)
+		}
+	}
+}
+
+func TestOverlays(t *testing.T) {
+	mx := mtx.NewDense(3, 2, []float64{1, 1, 2, 4, 3, 9})
+	cfg := Config{
+		Columns:  []string{"x", "y"},
+		Width:    4 * vg.Inch,
+		Height:   4 * vg.Inch,
+		Overlays: []Overlay{{Name: "y fit", Func: func(x float64) float64 { return x * x }}},
+	}
+	canvas, err := New(cfg, mx)
+	assert.NoError(t, err)
+	assert.NotNil(t, canvas)
+}
+
+func TestMissingValues(t *testing.T) {
+	mx := mtx.NewDense(3, 2, []float64{1, 1, 2, math.NaN(), 3, 3})
+	data := GetLines(mx, []string{"x", "y"})
+	assert.Equal(t, 2, data[1].(plotter.XYs).Len())
+	values := GetValues(mx)
+	assert.Len(t, values[1], 2)
+	cfg := Config{Columns: []string{"x", "y"}, Dashed: map[string]string{"y": "x"}, Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err := New(cfg, mx)
+	assert.NoError(t, err)
+}
+
+func TestDashedColors(t *testing.T) {
+	cfg := Config{
+		Dashed:  map[string]string{"y_forecast": "y", "y_lower": "y", "w_forecast": "w"},
+		Markers: []Marker{{Column: "z", Flag: "z_anomaly"}},
+	}
+	colors := cfg.colors([]string{"z", "z_anomaly", "y", "y_forecast", "y_lower", "w_forecast"})
+	assert.Equal(t, map[string]int{"z": 0, "y": 1, "y_forecast": 1, "y_lower": 1, "w_forecast": 2}, colors)
+}
+
+func TestMarkers(t *testing.T) {
+	mx := mtx.NewDense(3, 3, []float64{1, 1, 0, 2, 9, 1, 3, math.NaN(), 1})
+	columns := []string{"x", "y", "y_anomaly"}
+	marker := Marker{Column: "y", Flag: "y_anomaly"}
+	xys := GetMarkers(mx, columns, marker)
+	assert.Equal(t, 1, xys.Len())
+	x, y := xys.XY(0)
+	assert.Equal(t, 2.0, x)
+	assert.Equal(t, 9.0, y)
+	assert.Equal(t, 0, GetMarkers(mx, columns, Marker{Column: "nope", Flag: "y_anomaly"}).Len())
+	cfg := Config{Columns: columns, Markers: []Marker{marker}, Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err := New(cfg, mx)
+	assert.NoError(t, err)
+}
+
+func TestLogScale(t *testing.T) {
+	xys := positive(plotter.XYs{{X: 0, Y: 1}, {X: 1, Y: 0}, {X: 2, Y: 3}}, true, true)
+	assert.Equal(t, plotter.XYs{{X: 2, Y: 3}}, xys)
+	assert.Len(t, positive(xys, false, false), 1)
+	mx := mtx.NewDense(3, 2, []float64{0, 1, 1, 0, 2, 3})
+	cfg := Config{Columns: []string{"frequency", "power"}, LogX: true, LogY: true, Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err := New(cfg, mx)
+	assert.NoError(t, err)
+}
+
+func TestHistogram(t *testing.T) {
+	mx := mtx.NewDense(2, 3, []float64{0, 10, 3, 10, 20, 5})
+	hist, err := GetHistogram(mx)
+	assert.NoError(t, err)
+	assert.Len(t, hist.Bins, 2)
+	assert.Equal(t, plotter.HistogramBin{Min: 10, Max: 20, Weight: 5}, hist.Bins[1])
+	_, err = GetHistogram(mtx.NewDense(1, 2, nil))
+	assert.Error(t, err)
+	cfg := Config{Type: "histogram", Columns: []string{"bin_start", "bin_end", "count"}, Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err = New(cfg, mx)
+	assert.NoError(t, err)
+}
+
+func TestScatters(t *testing.T) {
+	mx := mtx.NewDense(4, 3, []float64{
+		1, 1, 0,
+		2, 4, 1,
+		3, math.NaN(), 0,
+		4, 16, 0,
+	})
+	columns := []string{"x", "y", "cluster"}
+	data := GetScatters(mx, columns, "cluster")
+	assert.Len(t, data, 4)
+	assert.Equal(t, "y cluster 0", data[0])
+	assert.Equal(t, 2, data[1].(plotter.XYs).Len())
+	assert.Equal(t, "y cluster 1", data[2])
+	assert.Equal(t, 1, data[3].(plotter.XYs).Len())
+	assert.Len(t, GetScatters(mx, columns, ""), 4)
+	cfg := Config{Type: "scatter", Columns: columns, Group: "cluster", Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err := New(cfg, mx)
+	assert.NoError(t, err)
+}
+
+func TestHeatmap(t *testing.T) {
+	mx := mtx.NewDense(2, 3, []float64{1, 1, -0.5, 2, -0.5, 1})
+	grid, err := GetGrid(mx)
+	assert.NoError(t, err)
+	c, r := grid.Dims()
+	assert.Equal(t, 2, c)
+	assert.Equal(t, 2, r)
+	assert.Equal(t, -0.5, grid.Z(1, 0))
+	assert.Equal(t, 2.0, grid.X(1))
+	assert.Equal(t, 2.0, grid.Y(1))
+	_, err = GetGrid(mtx.NewDense(2, 1, nil))
+	assert.Error(t, err)
+	columns := []string{"column", "x", "y"}
+	assert.Equal(t, plot.ConstantTicks{{Value: 1, Label: "x"}, {Value: 2, Label: "y"}}, ColumnTicks(columns))
+	cfg := Config{Type: "heatmap", Columns: columns, Width: 4 * vg.Inch, Height: 4 * vg.Inch}
+	_, err = New(cfg, mx)
+	assert.NoError(t, err)
+}
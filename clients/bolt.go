@@ -2,9 +2,11 @@ package clients
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/boltdb/bolt"
 	mtx "github.com/gonum/matrix/mat64"
 	"github.com/kevinschoon/fit/types"
+	"math"
 	"time"
 )
 
@@ -95,6 +97,86 @@ func (c *BoltClient) Delete(name string) error {
 	})
 }
 
+// Rename changes the name of a dataset. The
+// dataset and its matrix are moved to the new
+// key within a single transaction.
+func (c *BoltClient) Rename(name, newName string) error {
+	return c.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dsBucket)
+		raw := b.Get([]byte(name))
+		if raw == nil {
+			return types.ErrNotFound
+		}
+		if b.Get([]byte(newName)) != nil {
+			return types.ErrExists
+		}
+		ds := &types.Dataset{}
+		if err := json.Unmarshal(raw, ds); err != nil {
+			return err
+		}
+		ds.Name = newName
+		raw, err := json.Marshal(ds)
+		if err != nil {
+			return err
+		}
+		if err = b.Put([]byte(newName), raw); err != nil {
+			return err
+		}
+		if err = b.Delete([]byte(name)); err != nil {
+			return err
+		}
+		b = tx.Bucket(mxBucket)
+		raw = b.Get([]byte(name))
+		if raw == nil {
+			return nil // No matricies attached to the dataset
+		}
+		// Values returned by Get are only valid for
+		// the life of the transaction and cannot be
+		// written back after the key is deleted.
+		raw = append([]byte(nil), raw...)
+		if err = b.Put([]byte(newName), raw); err != nil {
+			return err
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// RenameColumn changes the name of a single
+// column within a dataset
+func (c *BoltClient) RenameColumn(name, column, newColumn string) error {
+	return c.bolt.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(dsBucket)
+		raw := b.Get([]byte(name))
+		if raw == nil {
+			return types.ErrNotFound
+		}
+		ds := &types.Dataset{}
+		if err := json.Unmarshal(raw, ds); err != nil {
+			return err
+		}
+		pos := ds.CPos(column)
+		if pos < 0 {
+			return types.ErrNotFound
+		}
+		if ds.CPos(newColumn) >= 0 {
+			return types.ErrExists
+		}
+		ds.Columns[pos] = newColumn
+		raw, err := json.Marshal(ds)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(name), raw)
+	})
+}
+
+// unitColumn is the unit of a queried column
+type unitColumn struct {
+	dataset string
+	column  string
+	unit    string
+}
+
 // Query finds all of the datasets contained
 // in Queries and returns a combined dataset
 // for each column in the search. The values
@@ -121,6 +203,9 @@ func (c *BoltClient) Query(query *types.Query) (*types.Dataset, error) {
 	vectors := make([]*mtx.Vector, 0)
 	// Map of datasets already processed
 	processed := make(map[string]*types.Dataset)
+	// Map of the first column queried for each
+	// kind of unit used to detect mismatches
+	units := make(map[string]unitColumn)
 	// Range each dataset in the query
 	for _, dataset := range query.Datasets {
 		columns := dataset.Columns
@@ -163,8 +248,23 @@ func (c *BoltClient) Query(query *types.Query) (*types.Dataset, error) {
 			}
 			// Append the column to vectors array
 			vectors = append(vectors, other.Mtx.ColView(pos))
-			// Add the column name to the resulting dataset
+			// Add the column name and metadata to the resulting dataset
 			ds.Columns = append(ds.Columns, name)
+			ds.Meta = append(ds.Meta, other.ColumnMeta(pos))
+			// Warn if the column uses a different unit than a
+			// column of the same kind from another dataset
+			current := unitColumn{dataset: dataset.Name, column: name, unit: other.ColumnMeta(pos).Unit}
+			if unit, err := types.GetUnit(current.unit); err == nil {
+				if previous, ok := units[unit.Kind]; !ok {
+					units[unit.Kind] = current
+				} else if previous.dataset != current.dataset && previous.unit != current.unit {
+					ds.Warnings = append(ds.Warnings, fmt.Sprintf(
+						"unit mismatch: %s,%s is %s but %s,%s is %s",
+						previous.dataset, previous.column, previous.unit,
+						current.dataset, current.column, current.unit,
+					))
+				}
+			}
 		}
 	}
 	// Resulting number of columns is equal to
@@ -177,11 +277,15 @@ func (c *BoltClient) Query(query *types.Query) (*types.Dataset, error) {
 		for j := 0; j < cols; j++ {
 			if vectors[j].Len() > i {
 				ds.Mtx.Set(i, j, vectors[j].At(i, 0))
-			} // Zeros are left for missing data
+			} else { // Missing data is NaN
+				ds.Mtx.Set(i, j, math.NaN())
+			}
 		}
 	}
 	// Apply any other query options to the resulting dataset
-	ds.Mtx = query.Apply(ds.Mtx)
+	if err := query.Apply(ds); err != nil {
+		return nil, err
+	}
 	return ds, nil
 }
 
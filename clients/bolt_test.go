@@ -5,6 +5,7 @@ import (
 	"github.com/kevinschoon/fit/types"
 	"github.com/stretchr/testify/assert"
 	"io/ioutil"
+	"math"
 	"math/rand"
 	"os"
 	"testing"
@@ -115,6 +116,9 @@ func TestQuery(t *testing.T) {
 	assert.Equal(t, 3.0, mx.At(0, ds.CPos("G")))
 	assert.Equal(t, 2.0, mx.At(1, ds.CPos("G")))
 	assert.Equal(t, 1.0, mx.At(2, ds.CPos("G")))
+	// Shorter columns are padded with NaN
+	assert.True(t, math.IsNaN(mx.At(2, ds.CPos("A"))))
+	assert.True(t, math.IsNaN(mx.At(4, ds.CPos("G"))))
 	_, err = db.Query(types.NewQuery([]string{"mx3"}, "", ""))
 	assert.Error(t, err, "not found")
 	_, err = db.Query(types.NewQuery([]string{"mx1,H"}, "", ""))
@@ -127,6 +131,63 @@ func TestQuery(t *testing.T) {
 	assert.Equal(t, 4, c)
 }
 
+func TestQueryUnits(t *testing.T) {
+	db, cleanup := NewTestDB(t)
+	defer cleanup()
+	assert.NoError(t, db.Write(&types.Dataset{
+		Name:    "A",
+		Mtx:     NewTestMatrix(2, 2),
+		Columns: []string{"time", "temp"},
+		Meta:    []types.Meta{{Precision: types.Seconds}, {Unit: "degF"}},
+	}))
+	assert.NoError(t, db.Write(&types.Dataset{
+		Name:    "B",
+		Mtx:     NewTestMatrix(2, 2),
+		Columns: []string{"temp", "distance"},
+		Meta:    []types.Meta{{Unit: "degC"}, {Unit: "km"}},
+	}))
+	ds, err := db.Query(types.NewQuery([]string{"A,time,temp", "B,distance"}, "", ""))
+	assert.NoError(t, err)
+	assert.Len(t, ds.Warnings, 0)
+	assert.Equal(t, "km", ds.ColumnMeta(2).Unit)
+	ds, err = db.Query(types.NewQuery([]string{"A,time,temp", "B,temp"}, "", ""))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unit mismatch: A,temp is degF but B,temp is degC"}, ds.Warnings)
+}
+
+func TestRename(t *testing.T) {
+	d, cleanup := NewTestDB(t)
+	defer cleanup()
+	db := d.(*BoltClient)
+	assert.NoError(t, db.Write(&types.Dataset{
+		Name:    "mx1",
+		Mtx:     NewTestMatrix(4, 2),
+		Columns: []string{"A", "B"}}),
+	)
+	assert.NoError(t, db.Write(&types.Dataset{
+		Name:    "mx2",
+		Columns: []string{"C"}}),
+	)
+	assert.Equal(t, types.ErrExists, db.Rename("mx1", "mx2"))
+	assert.Equal(t, types.ErrNotFound, db.Rename("mx3", "mx4"))
+	assert.NoError(t, db.Rename("mx1", "mx3"))
+	_, err := db.read("mx1")
+	assert.Equal(t, types.ErrNotFound, err)
+	ds, err := db.read("mx3")
+	assert.NoError(t, err)
+	assert.Equal(t, "mx3", ds.Name)
+	r, c := ds.Mtx.Dims()
+	assert.Equal(t, 4, r)
+	assert.Equal(t, 2, c)
+	assert.Equal(t, types.ErrExists, db.RenameColumn("mx3", "A", "B"))
+	assert.Equal(t, types.ErrNotFound, db.RenameColumn("mx3", "Z", "Y"))
+	assert.NoError(t, db.RenameColumn("mx3", "A", "Z"))
+	ds, err = db.read("mx3")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Z", "B"}, ds.Columns)
+	assert.Equal(t, 4, ds.Len())
+}
+
 func init() {
 	rand.Seed(time.Now().Unix())
 }
@@ -45,6 +45,8 @@ func (c *HTTPClient) do(req *http.Request) ([]byte, error) {
 		switch res.StatusCode {
 		case 404:
 			return nil, types.ErrNotFound
+		case 409:
+			return nil, types.ErrExists
 		default:
 			return nil, types.ErrAPI
 		}
@@ -88,6 +90,33 @@ func (c *HTTPClient) Delete(name string) (err error) {
 	return err
 }
 
+func (c *HTTPClient) Rename(name, newName string) (err error) {
+	u := c.url()
+	u.RawQuery = url.Values{
+		"name":   []string{name},
+		"rename": []string{newName},
+	}.Encode()
+	_, err = c.do(&http.Request{
+		URL:    u,
+		Method: "PUT",
+	})
+	return err
+}
+
+func (c *HTTPClient) RenameColumn(name, column, newColumn string) (err error) {
+	u := c.url()
+	u.RawQuery = url.Values{
+		"name":   []string{name},
+		"column": []string{column},
+		"rename": []string{newColumn},
+	}.Encode()
+	_, err = c.do(&http.Request{
+		URL:    u,
+		Method: "PUT",
+	})
+	return err
+}
+
 func (c *HTTPClient) Query(query *types.Query) (ds *types.Dataset, err error) {
 	u := c.url()
 	u.RawQuery = query.String()
@@ -8,11 +8,16 @@ import (
 	"github.com/jawher/mow.cli"
 
 	"github.com/kevinschoon/fit/clients"
+	"github.com/kevinschoon/fit/hypothesis"
 	"github.com/kevinschoon/fit/loader"
 	"github.com/kevinschoon/fit/parser"
+	"github.com/kevinschoon/fit/regression"
 	"github.com/kevinschoon/fit/server"
 	"github.com/kevinschoon/fit/types"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const FitVersion string = "0.0.1"
@@ -54,6 +59,14 @@ func GetClient(pref string) types.Client {
 	return client
 }
 
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
 func Run() {
 	app.Version("v version", FitVersion)
 
@@ -69,27 +82,51 @@ func Run() {
 	})
 
 	app.Command("load", "load a dataset into BoltDB", func(cmd *cli.Cmd) {
-		cmd.Spec = "[[-n] [-s]][-p...][-c...] PATH"
+		cmd.Spec = "[OPTIONS] PATH"
 		var (
 			name       = cmd.StringOpt("n name", "", "name of this dataset")
 			path       = cmd.StringArg("PATH", "", "File path")
 			parserArgs = cmd.StringsOpt("p parser", []string{}, "parsers to apply")
+			parserFile = cmd.StringOpt("P parser-file", "", "file containing parsers to apply, one per line")
 			sheet      = cmd.StringOpt("s sheet", "", "name of the sheet to load with XLS file")
+			allSheets  = cmd.BoolOpt("a all-sheets", false, "load every sheet of an XLS file as a separate dataset")
+			header     = cmd.IntOpt("header", 0, "row containing column names in an XLS file, -1 if none")
 			columns    = cmd.StringsOpt("c column", []string{}, "column names")
+			strict     = cmd.BoolOpt("strict", false, "stop at the first value which cannot be parsed")
+			maxErrors  = cmd.IntOpt("max-errors", 20, "maximum number of values which cannot be parsed to report")
 		)
 		cmd.Action = func() {
+			defaults := parser.Parsers{}
+			if *parserFile != "" {
+				fromFile, err := parser.ParsersFromFile(*parserFile)
+				FailOnErr(err)
+				defaults = fromFile
+			}
+			// Arguments take precedence over the parser file
 			parsers, err := parser.ParsersFromArgs(*parserArgs)
 			FailOnErr(err)
 			opts := loader.Options{
-				Name:    *name,
-				Path:    *path,
-				Parsers: parsers,
-				Columns: *columns,
-				Sheet:   *sheet,
+				Name:      *name,
+				Path:      *path,
+				Parsers:   parsers,
+				Defaults:  defaults,
+				Columns:   *columns,
+				Sheet:     *sheet,
+				AllSheets: *allSheets,
+				Header:    *header,
+				Strict:    *strict,
+				Report:    &loader.Report{Max: *maxErrors},
 			}
-			ds, err := loader.ReadPath(opts)
+			datasets, err := loader.ReadAll(opts)
 			FailOnErr(err)
-			FailOnErr(GetClient("").Write(ds))
+			client := GetClient("")
+			for _, ds := range datasets {
+				FailOnErr(client.Write(ds))
+			}
+			if opts.Report.Total > 0 {
+				fmt.Printf("WARNING: %d values could not be parsed and were stored as NaN\n", opts.Report.Total)
+				fmt.Print(opts.Report)
+			}
 		}
 	})
 
@@ -124,19 +161,493 @@ func Run() {
 		}
 	})
 
+	app.Command("rename", "Rename a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "NAME NEW"
+		var (
+			name    = cmd.StringArg("NAME", "", "Name of the dataset to rename")
+			newName = cmd.StringArg("NEW", "", "New name of the dataset")
+		)
+		cmd.Action = func() {
+			FailOnErr(GetClient("").Rename(*name, *newName))
+		}
+	})
+
+	app.Command("rename-column", "Rename a column within a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "NAME OLD NEW"
+		var (
+			name      = cmd.StringArg("NAME", "", "Name of the dataset")
+			column    = cmd.StringArg("OLD", "", "Name of the column to rename")
+			newColumn = cmd.StringArg("NEW", "", "New name of the column")
+		)
+		cmd.Action = func() {
+			FailOnErr(GetClient("").RenameColumn(*name, *column, *newColumn))
+		}
+	})
+
+	app.Command("derive", "Add a column computed from an expression to a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "NAME COLUMN EXPR"
+		var (
+			name   = cmd.StringArg("NAME", "", "Name of the dataset")
+			column = cmd.StringArg("COLUMN", "", "Name of the new column")
+			expr   = cmd.StringArg("EXPR", "", "Expression computing the new column")
+		)
+		cmd.LongDesc = `Add a column computed from an expression of other columns to a dataset.
+
+Example:
+
+fit derive Dataset1 temp_c "(temp_f - 32) * 5 / 9"
+`
+		cmd.Action = func() {
+			client := GetClient("")
+			query := types.NewQuery([]string{fmt.Sprintf("%s,*", *name)}, "", "")
+			query.Derived = []types.Derived{{Name: *column, Expr: *expr}}
+			ds, err := client.Query(query)
+			FailOnErr(err)
+			ds.Name = *name
+			ds.Warnings = nil
+			FailOnErr(client.Write(ds))
+		}
+	})
+
+	app.Command("clean", "Fill or drop missing values in a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME"
+		var (
+			name   = cmd.StringArg("NAME", "", "Name of the dataset")
+			fills  = cmd.StringsOpt("fill", []string{}, "missing values to fill as ffill|bfill|linear|constant,COLUMN[,VALUE]")
+			dropna = cmd.StringsOpt("dropna", []string{}, "drop rows missing a value in the column, * for any column")
+		)
+		cmd.LongDesc = `Fill or drop missing (NaN) values in a dataset and store the result.
+Fills are applied in order before any rows are dropped.
+
+Example:
+
+fit clean --fill ffill,fuu --fill constant,bar,0 --dropna baz Dataset1
+`
+		cmd.Action = func() {
+			client := GetClient("")
+			query := types.NewQuery([]string{fmt.Sprintf("%s,*", *name)}, "", "")
+			for _, fill := range *fills {
+				query.Fills = append(query.Fills, types.NewFill(fill))
+			}
+			query.DropNA = *dropna
+			ds, err := client.Query(query)
+			FailOnErr(err)
+			ds.Name = *name
+			ds.Warnings = nil
+			FailOnErr(client.Write(ds))
+		}
+	})
+
+	app.Command("regress", "Fit a linear model to the columns of a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME FORMULA..."
+		var (
+			name    = cmd.StringArg("NAME", "", "Name of the dataset")
+			formula = cmd.StringsArg("FORMULA", []string{}, "Formula of the model, e.g. y ~ x1 + x2")
+			weights = cmd.StringOpt("w weights", "", "column containing the weight of each row")
+		)
+		cmd.LongDesc = `Fit a linear model to the columns of a dataset with least squares.
+Terms may be raised to a power with ^ and poly(x, N) adds each power of x up
+to N. An intercept is included unless the term 0 is given.
+
+Example:
+
+fit regress Dataset1 y ~ x1 + x2
+fit regress -w weight Dataset1 "y ~ poly(x, 3)"
+`
+		cmd.Action = func() {
+			f, err := regression.ParseFormula(strings.Join(*formula, " "))
+			FailOnErr(err)
+			ds, err := GetClient("").Query(types.NewQuery([]string{fmt.Sprintf("%s,*", *name)}, "", ""))
+			FailOnErr(err)
+			model, err := regression.Fit(ds, f, *weights)
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(model)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow("TERM", "COEFFICIENT", "STD ERROR")
+				for i, term := range model.Terms {
+					tbl.AddRow(term, fmt.Sprintf("%g", model.Coefficients[i]), fmt.Sprintf("%g", model.StdErrors[i]))
+				}
+				fmt.Printf("\n%s\n\n", model.Formula)
+				fmt.Println(tbl)
+				fmt.Printf("\nR²: %g  Observations: %d\n\n", model.RSquared, model.Observations)
+			}
+		}
+	})
+
+	app.Command("curve", "Fit a nonlinear curve to two columns of a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME CURVE X Y"
+		var (
+			name  = cmd.StringArg("NAME", "", "Name of the dataset")
+			curve = cmd.StringArg("CURVE", "", "Curve to fit: exp, logistic, power or gaussian")
+			x     = cmd.StringArg("X", "", "Column of X values")
+			y     = cmd.StringArg("Y", "", "Column of Y values")
+			guess = cmd.StringOpt("g guess", "", "comma separated initial value of each parameter")
+		)
+		cmd.LongDesc = `Fit a nonlinear curve to two columns of a dataset with the
+Levenberg-Marquardt algorithm. Initial parameters are estimated from
+the data unless they are given with --guess.
+
+Curves:
+
+exp       a * exp(-b * x) + c
+logistic  L / (1 + exp(-k * (x - x0)))
+power     a * x^b
+gaussian  a * exp(-(x - mu)^2 / (2 * sigma^2))
+
+Example:
+
+fit curve Dataset1 exp time counts
+fit curve -g 100,0.5,10 Dataset1 logistic time population
+`
+		cmd.Action = func() {
+			c, err := regression.GetCurve(*curve)
+			FailOnErr(err)
+			var initial []float64
+			if *guess != "" {
+				for _, value := range strings.Split(*guess, ",") {
+					v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+					FailOnErr(err)
+					initial = append(initial, v)
+				}
+			}
+			ds, err := GetClient("").Query(types.NewQuery([]string{fmt.Sprintf("%s,%s,%s", *name, *x, *y)}, "", ""))
+			FailOnErr(err)
+			fit, err := regression.FitCurveColumns(ds, c, *x, *y, initial)
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(fit)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow("PARAM", "VALUE", "STD ERROR", "95% CI")
+				for i, param := range fit.Params {
+					tbl.AddRow(param, fmt.Sprintf("%g", fit.Values[i]), fmt.Sprintf("%g", fit.StdErrors[i]), fmt.Sprintf("%g to %g", fit.Lower[i], fit.Upper[i]))
+				}
+				fmt.Printf("\n%s\n\n", fit.Formula)
+				fmt.Println(tbl)
+				fmt.Printf("\nR²: %g  Observations: %d  Iterations: %d\n\n", fit.RSquared, fit.Observations, fit.Iterations)
+			}
+		}
+	})
+
+	app.Command("corr", "Correlation matrix of the columns of one or more datasets", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME [COLUMNS...]"
+		var (
+			name    = cmd.StringArg("NAME", "", "Name of the dataset")
+			columns = cmd.StringsArg("COLUMNS", []string{}, "Columns to correlate, default all")
+			method  = cmd.StringOpt("m method", "pearson", "pearson, spearman, kendall or cov for covariance")
+			with    = cmd.StringsOpt("with", []string{}, "columns joined from another dataset as NAME,COLUMN...")
+		)
+		cmd.LongDesc = `Compute the correlation (or covariance) between each pair of columns.
+Rows missing either value of a pair are ignored. The first column of
+the result holds the position of the column each row corresponds to.
+
+Example:
+
+fit corr -m spearman Dataset1 fuu bar
+fit corr Dataset1 fuu --with Dataset2,baz
+`
+		cmd.Action = func() {
+			args := []string{fmt.Sprintf("%s,*", *name)}
+			if len(*columns) > 0 {
+				args[0] = strings.Join(append([]string{*name}, *columns...), ",")
+			}
+			query := types.NewQuery(append(args, *with...), "", "")
+			query.Correlation = &types.Correlation{Method: *method}
+			ds, err := GetClient("").Query(query)
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				ds.WithValues = true
+				raw, err := json.Marshal(ds)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow(append([]interface{}{""}, toInterfaces(ds.Columns[1:])...)...)
+				for i := 0; i < ds.Len(); i++ {
+					row := []interface{}{ds.Columns[int(ds.Mtx.At(i, 0))]}
+					for j := 1; j < len(ds.Columns); j++ {
+						row = append(row, fmt.Sprintf("%.4f", ds.Mtx.At(i, j)))
+					}
+					tbl.AddRow(row...)
+				}
+				fmt.Println(tbl)
+			}
+		}
+	})
+
+	app.Command("kmeans", "Cluster the rows of a dataset with k-means", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME K [COLUMNS...]"
+		var (
+			name    = cmd.StringArg("NAME", "", "Name of the dataset")
+			k       = cmd.IntArg("K", 2, "Number of clusters")
+			columns = cmd.StringsArg("COLUMNS", []string{}, "Columns to cluster on, default all")
+			seed    = cmd.IntOpt("seed", 1, "seed of the random initial centroids")
+		)
+		cmd.LongDesc = `Partition the rows of a dataset into K clusters with k-means, printing
+the size and centroid of each cluster. Rows missing a value are ignored.
+Add the cluster of each row to a query with --kmeans.
+
+Example:
+
+fit kmeans Dataset1 3 fuu bar
+fit query --kmeans 3,fuu,bar "Dataset1,fuu,bar"
+`
+		cmd.Action = func() {
+			args := []string{fmt.Sprintf("%s,*", *name)}
+			if len(*columns) > 0 {
+				args[0] = strings.Join(append([]string{*name}, *columns...), ",")
+			}
+			ds, err := GetClient("").Query(types.NewQuery(args, "", ""))
+			FailOnErr(err)
+			clusters, err := types.KMeans{K: *k, Seed: int64(*seed)}.Fit(ds.Mtx)
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(clusters)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow(append([]interface{}{"CLUSTER", "SIZE"}, toInterfaces(ds.Columns)...)...)
+				for i, centroid := range clusters.Centroids {
+					row := []interface{}{i, clusters.Sizes[i]}
+					for _, value := range centroid {
+						row = append(row, fmt.Sprintf("%g", value))
+					}
+					tbl.AddRow(row...)
+				}
+				fmt.Println(tbl)
+				fmt.Printf("\nInertia: %g  Iterations: %d\n\n", clusters.Inertia, clusters.Iterations)
+			}
+		}
+	})
+
+	app.Command("pca", "Principal component analysis of the columns of a dataset", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME [COLUMNS...]"
+		var (
+			name       = cmd.StringArg("NAME", "", "Name of the dataset")
+			columns    = cmd.StringsArg("COLUMNS", []string{}, "Columns to analyse, default all")
+			components = cmd.IntOpt("n components", 0, "number of components, default all")
+			scale      = cmd.BoolOpt("scale", false, "divide each column by its standard deviation")
+		)
+		cmd.LongDesc = `Find the principal components of the columns of a dataset, printing
+the variance each explains and the weight of each column. Rows missing
+a value are ignored. Project the rows onto the components with --pca.
+
+Example:
+
+fit pca --scale Dataset1 fuu bar baz
+fit query --pca 2,fuu,bar,baz,scale "Dataset1,fuu,bar,baz"
+`
+		cmd.Action = func() {
+			args := []string{fmt.Sprintf("%s,*", *name)}
+			if len(*columns) > 0 {
+				args[0] = strings.Join(append([]string{*name}, *columns...), ",")
+			}
+			ds, err := GetClient("").Query(types.NewQuery(args, "", ""))
+			FailOnErr(err)
+			pc, err := types.PCA{Components: *components, Scale: *scale}.Fit(ds.Mtx)
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(pc)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow(append([]interface{}{"COMPONENT", "VARIANCE", "RATIO"}, toInterfaces(ds.Columns)...)...)
+				for i, component := range pc.Names() {
+					row := []interface{}{component, fmt.Sprintf("%g", pc.Variance[i]), fmt.Sprintf("%.4f", pc.Ratio[i])}
+					for _, loading := range pc.Loadings[i] {
+						row = append(row, fmt.Sprintf("%.4f", loading))
+					}
+					tbl.AddRow(row...)
+				}
+				fmt.Println(tbl)
+			}
+		}
+	})
+
+	app.Command("anomalies", "List rows of a dataset with outlying values", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] NAME COLUMN"
+		var (
+			name      = cmd.StringArg("NAME", "", "Name of the dataset")
+			column    = cmd.StringArg("COLUMN", "", "Column to search for outliers")
+			method    = cmd.StringOpt("m method", "zscore", "zscore, hampel or iqr")
+			threshold = cmd.StringOpt("t threshold", "", "outlier threshold, default 3 or 1.5 for iqr")
+			window    = cmd.IntOpt("w window", 3, "rows either side of each value for hampel")
+			index     = cmd.IntOpt("i index", 0, "position of the time column")
+		)
+		cmd.LongDesc = `List each row of a dataset where the value of a column is an outlier.
+
+Methods:
+
+zscore  more than THRESHOLD standard deviations from the mean
+hampel  more than THRESHOLD scaled median absolute deviations from
+        the median of the WINDOW rows either side
+iqr     outside the quartiles by more than THRESHOLD times the
+        interquartile range
+
+Example:
+
+fit anomalies Dataset1 fuu
+fit anomalies -m hampel -t 2 -w 10 Dataset1 fuu
+`
+		cmd.Action = func() {
+			anomaly := types.NewAnomaly(fmt.Sprintf("%s,%s,%s,%d", *method, *column, *threshold, *window))
+			query := types.NewQuery([]string{fmt.Sprintf("%s,*", *name)}, "", "")
+			query.Anomalies = []*types.Anomaly{anomaly}
+			ds, err := GetClient("").Query(query)
+			FailOnErr(err)
+			var (
+				flag      = ds.CPos(anomaly.Output())
+				value     = ds.CPos(*column)
+				precision = ds.ColumnMeta(*index).Precision
+				rows      []map[string]interface{}
+			)
+			for i := 0; i < ds.Len(); i++ {
+				if ds.Mtx.At(i, flag) != 1 {
+					continue
+				}
+				row := map[string]interface{}{"row": i, "value": ds.Mtx.At(i, value)}
+				if *index >= 0 && *index < len(ds.Columns) {
+					if precision != "" {
+						row["time"] = precision.Time(ds.Mtx.At(i, *index)).Format(time.RFC3339)
+					} else {
+						row["time"] = fmt.Sprintf("%g", ds.Mtx.At(i, *index))
+					}
+				}
+				rows = append(rows, row)
+			}
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(rows)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow("ROW", "TIME", "VALUE")
+				for _, row := range rows {
+					tbl.AddRow(row["row"], row["time"], fmt.Sprintf("%g", row["value"]))
+				}
+				fmt.Println(tbl)
+				fmt.Printf("\n%d of %d rows flagged\n\n", len(rows), ds.Len())
+			}
+		}
+	})
+
+	app.Command("test", "Test whether two columns differ significantly", func(cmd *cli.Cmd) {
+		cmd.Spec = "[OPTIONS] TEST X Y"
+		var (
+			test     = cmd.StringArg("TEST", "", "Test to run: welch, paired, mann-whitney, ks or chi-square")
+			x        = cmd.StringArg("X", "", "First sample as a query NAME,[TIME,]COLUMN")
+			y        = cmd.StringArg("Y", "", "Second sample as a query NAME,[TIME,]COLUMN")
+			grouping = cmd.StringOpt("g grouping", "", "grouping to apply to each sample")
+			function = cmd.StringOpt("f function", "avg", "function to apply when grouping")
+		)
+		cmd.LongDesc = `Compare two samples, each the last column of a query, printing the
+test statistic, its two sided p-value and the effect size. Missing
+values are ignored. To group a sample select its time column first.
+
+Tests:
+
+welch         difference in means without equal variances, cohen's d
+paired        mean difference between rows of equal samples, cohen's d
+mann-whitney  difference in ranks, rank biserial correlation
+ks            largest difference between the distributions, D
+chi-square    difference in the frequency of categories, cramér's v
+
+Example:
+
+fit test welch Before,temp After,temp
+fit test -g Duration,0,1h paired Before,time,temp After,time,temp
+`
+		cmd.Action = func() {
+			t, err := hypothesis.GetTest(*test)
+			FailOnErr(err)
+			samples := make([][]float64, 2)
+			for i, arg := range []string{*x, *y} {
+				ds, err := GetClient("").Query(types.NewQuery([]string{arg}, *function, *grouping))
+				FailOnErr(err)
+				if len(ds.Columns) == 0 {
+					FailOnErr(types.ErrNotFound)
+				}
+				samples[i] = mtx.Col(nil, len(ds.Columns)-1, ds.Mtx)
+			}
+			result, err := t(samples[0], samples[1])
+			FailOnErr(err)
+			switch {
+			case *asJSON:
+				raw, err := json.Marshal(result)
+				FailOnErr(err)
+				fmt.Println(string(raw))
+			default:
+				tbl := uitable.New()
+				tbl.AddRow("TEST", "STATISTIC", "DF", "P-VALUE", "EFFECT", "N1", "N2")
+				df := ""
+				if result.DF > 0 {
+					df = fmt.Sprintf("%g", result.DF)
+				}
+				tbl.AddRow(result.Test, fmt.Sprintf("%g", result.Statistic), df, fmt.Sprintf("%.4g", result.PValue),
+					fmt.Sprintf("%.4g (%s)", result.Effect, result.EffectName), result.N1, result.N2)
+				fmt.Println(tbl)
+			}
+		}
+	})
+
 	app.Command("query", "Query values from one or more datasets", func(cmd *cli.Cmd) {
 		var (
-			queryArgs = cmd.StringsArg("QUERY", []string{}, "Query parameters")
-			lines     = cmd.IntOpt("n lines", 10, "number of rows to output")
-			grouping  = cmd.StringOpt("g grouping", "", "grouping to apply to the resulting matrix")
-			function  = cmd.StringOpt("f function", "avg", "function to apply when grouping")
+			queryArgs  = cmd.StringsArg("QUERY", []string{}, "Query parameters")
+			lines      = cmd.IntOpt("n lines", 10, "number of rows to output")
+			grouping   = cmd.StringOpt("g grouping", "", "grouping to apply to the resulting matrix")
+			function   = cmd.StringOpt("f function", "avg", "function to apply when grouping")
+			fills      = cmd.StringsOpt("fill", []string{}, "missing values to fill as ffill|bfill|linear|constant,COLUMN[,VALUE]")
+			dropna     = cmd.StringsOpt("dropna", []string{}, "drop rows missing a value in the column, * for any column")
+			resample   = cmd.StringOpt("r resample", "", "resample onto a regular time grid as INDEX,INTERVAL,nearest|previous|linear|spline[,START,END]")
+			exprs      = cmd.StringsOpt("e expr", []string{}, "columns computed from expressions as NAME=EXPR")
+			windows    = cmd.StringsOpt("w window", []string{}, "rolling window columns as FUNCTION,COLUMN,ROWS|DURATION[,INDEX]")
+			forecast   = cmd.StringOpt("forecast", "", "append a forecast as simple|holt|holt-winters,COLUMN,HORIZON[,PERIOD,INDEX]")
+			decompose  = cmd.StringOpt("decompose", "", "add trend, seasonal and residual columns as classical|stl,COLUMN,PERIOD")
+			kmeans     = cmd.StringOpt("kmeans", "", "add the cluster of each row as K[,COLUMN...]")
+			pca        = cmd.StringOpt("pca", "", "replace the result with its principal components as N[,COLUMN...][,scale]")
+			spectrum   = cmd.StringOpt("spectrum", "", "replace the result with its power spectrum as periodogram|welch,COLUMN[,SEGMENT,INDEX]")
+			acf        = cmd.StringOpt("acf", "", "replace the result with its autocorrelation as acf|pacf,COLUMN[,LAGS]")
+			histogram  = cmd.StringOpt("histogram", "", "replace the result with bins as width|quantile,COLUMN,BINS or edges,COLUMN,EDGES...[,density]")
+			corr       = cmd.StringOpt("corr", "", "replace the result with its pearson, spearman, kendall or cov matrix")
+			transforms = cmd.StringsOpt("t transform", []string{}, "transformed columns as diff|pct_change|cumsum|cumprod|lag|lead,COLUMN[,N]")
+			anomalies  = cmd.StringsOpt("a anomaly", []string{}, "columns flagging outliers as zscore|hampel|iqr,COLUMN[,THRESHOLD,WINDOW]")
+			sort       = cmd.StringOpt("sort", "", "comma separated columns to sort by, prefix with - for descending")
+			limit      = cmd.IntOpt("limit", 0, "maximum number of rows to return")
+			offset     = cmd.IntOpt("offset", 0, "number of rows to skip")
 		)
 		cmd.LongDesc = `Query values from one or more stored datasets. Values from different 
 datasets can be joined together by specifying multiple query parameters.
+Columns computed from expressions of other columns can be added with -e.
 
 Example:
 
 fit query -n 10 -g Duration,0,1m -f avg "Dataset1,fuu" "Dataset2,bar,baz"
+fit query -e "speed=distance/duration" "Dataset1,distance,duration"
+fit query -w avg,fuu,10 -w std,fuu,5m,0 "Dataset1,time,fuu"
+fit query -t diff,fuu -t lag,fuu,2 "Dataset1,time,fuu"
+fit query -a hampel,fuu,3,5 "Dataset1,time,fuu"
+fit query --fill linear,bar --dropna "*" "Dataset1,time,fuu" "Dataset2,bar"
+fit query -r 0,1m,linear "Dataset1,time,fuu"
+fit query -g Duration,0,1h --forecast holt-winters,fuu,24,24 "Dataset1,time,fuu"
+fit query --decompose stl,fuu,12 "Dataset1,time,fuu"
+fit query --acf pacf,fuu,24 "Dataset1,time,fuu"
+fit query -r 0,1s,linear --spectrum welch,fuu,128 "Dataset1,time,fuu"
+fit query --histogram quantile,fuu,4 "Dataset1,fuu"
+fit query --sort -speed,distance --limit 10 --offset 10 -e "speed=distance/duration" "Dataset1,distance,duration"
 `
 		cmd.Spec = "[OPTIONS] QUERY..."
 		cmd.Action = func() {
@@ -144,8 +655,58 @@ fit query -n 10 -g Duration,0,1m -f avg "Dataset1,fuu" "Dataset2,bar,baz"
 				cmd.PrintLongHelp()
 				os.Exit(1)
 			}
-			ds, err := GetClient("").Query(types.NewQuery(*queryArgs, *function, *grouping))
+			query := types.NewQuery(*queryArgs, *function, *grouping)
+			for _, fill := range *fills {
+				query.Fills = append(query.Fills, types.NewFill(fill))
+			}
+			query.DropNA = *dropna
+			if *resample != "" {
+				query.Resample = types.NewResample(*resample)
+			}
+			for _, expr := range *exprs {
+				query.Derived = append(query.Derived, types.NewDerived(expr))
+			}
+			for _, window := range *windows {
+				query.Windows = append(query.Windows, types.NewWindow(window))
+			}
+			for _, transform := range *transforms {
+				query.Transforms = append(query.Transforms, types.NewTransform(transform))
+			}
+			for _, anomaly := range *anomalies {
+				query.Anomalies = append(query.Anomalies, types.NewAnomaly(anomaly))
+			}
+			if *decompose != "" {
+				query.Decomposition = types.NewDecomposition(*decompose)
+			}
+			if *forecast != "" {
+				query.Forecast = types.NewForecast(*forecast)
+			}
+			if *kmeans != "" {
+				query.KMeans = types.NewKMeans(*kmeans)
+			}
+			if *pca != "" {
+				query.PCA = types.NewPCA(*pca)
+			}
+			if *spectrum != "" {
+				query.Spectrum = types.NewSpectrum(*spectrum)
+			}
+			if *acf != "" {
+				query.Autocorrelation = types.NewAutocorrelation(*acf)
+			}
+			if *histogram != "" {
+				query.Histogram = types.NewHistogram(*histogram)
+			}
+			if *corr != "" {
+				query.Correlation = &types.Correlation{Method: *corr}
+			}
+			query.Order = types.NewOrder(*sort)
+			query.Limit = *limit
+			query.Offset = *offset
+			ds, err := GetClient("").Query(query)
 			FailOnErr(err)
+			for _, warning := range ds.Warnings {
+				fmt.Fprintln(os.Stderr, "WARNING:", warning)
+			}
 			if ds.Len() > 0 {
 				switch {
 				case *asJSON:
@@ -0,0 +1,252 @@
+// Package hypothesis tests whether two samples
+// of values differ significantly.
+package hypothesis
+
+import (
+	"fmt"
+	"github.com/gonum/stat"
+	"github.com/gonum/stat/distuv"
+	"github.com/kevinschoon/fit/types"
+	"math"
+	"sort"
+)
+
+// Result is the outcome of a two sample test
+type Result struct {
+	Test       string
+	Statistic  float64
+	DF         float64 `json:",omitempty"` // Degrees of freedom of the statistic
+	PValue     float64 // Two sided probability of a statistic as extreme if the samples do not differ
+	Effect     float64 // Size of the difference between the samples
+	EffectName string
+	N1, N2     int // Values used from each sample
+}
+
+// Test compares two samples ignoring missing values
+type Test func(xs, ys []float64) (*Result, error)
+
+// Tests are the available tests by name
+var Tests = map[string]Test{
+	"welch":        Welch,
+	"paired":       Paired,
+	"mann-whitney": MannWhitney,
+	"ks":           KolmogorovSmirnov,
+	"chi-square":   ChiSquare,
+}
+
+// GetTest returns a Test by name
+func GetTest(name string) (Test, error) {
+	test, ok := Tests[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown test: %s", name)
+	}
+	return test, nil
+}
+
+// present returns the values which are not missing
+func present(values []float64) []float64 {
+	result := make([]float64, 0, len(values))
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// twoSided returns the probability of a t
+// statistic at least as far from zero
+func twoSided(t, df float64) float64 {
+	return 2 * (1 - distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}.CDF(math.Abs(t)))
+}
+
+// Welch compares the means of two samples without
+// assuming equal variances. The effect is Cohen's d.
+func Welch(xs, ys []float64) (*Result, error) {
+	xs, ys = present(xs), present(ys)
+	n1, n2 := float64(len(xs)), float64(len(ys))
+	if len(xs) < 2 || len(ys) < 2 {
+		return nil, fmt.Errorf("Welch's t-test requires at least two values in each sample")
+	}
+	m1, v1 := stat.MeanVariance(xs, nil)
+	m2, v2 := stat.MeanVariance(ys, nil)
+	se := math.Sqrt(v1/n1 + v2/n2)
+	if se == 0 {
+		return nil, fmt.Errorf("Samples have no variance")
+	}
+	t := (m1 - m2) / se
+	df := math.Pow(v1/n1+v2/n2, 2) / (math.Pow(v1/n1, 2)/(n1-1) + math.Pow(v2/n2, 2)/(n2-1))
+	pooled := math.Sqrt(((n1-1)*v1 + (n2-1)*v2) / (n1 + n2 - 2))
+	return &Result{
+		Test:       "welch",
+		Statistic:  t,
+		DF:         df,
+		PValue:     twoSided(t, df),
+		Effect:     (m1 - m2) / pooled,
+		EffectName: "cohen's d",
+		N1:         len(xs),
+		N2:         len(ys),
+	}, nil
+}
+
+// Paired compares the mean difference between pairs
+// of values at the same position to zero. Pairs missing
+// either value are ignored. The effect is Cohen's d of
+// the differences.
+func Paired(xs, ys []float64) (*Result, error) {
+	if len(xs) != len(ys) {
+		return nil, fmt.Errorf("Paired t-test requires samples of equal length")
+	}
+	diffs := make([]float64, 0, len(xs))
+	for i := range xs {
+		if d := xs[i] - ys[i]; !math.IsNaN(d) {
+			diffs = append(diffs, d)
+		}
+	}
+	n := float64(len(diffs))
+	if len(diffs) < 2 {
+		return nil, fmt.Errorf("Paired t-test requires at least two pairs")
+	}
+	m, sd := stat.MeanStdDev(diffs, nil)
+	if sd == 0 {
+		return nil, fmt.Errorf("Differences have no variance")
+	}
+	t := m / (sd / math.Sqrt(n))
+	return &Result{
+		Test:       "paired",
+		Statistic:  t,
+		DF:         n - 1,
+		PValue:     twoSided(t, n-1),
+		Effect:     m / sd,
+		EffectName: "cohen's d",
+		N1:         len(diffs),
+		N2:         len(diffs),
+	}, nil
+}
+
+// MannWhitney compares the ranks of two samples with
+// the normal approximation of the U statistic of xs,
+// corrected for ties and continuity. The effect is the
+// rank biserial correlation.
+func MannWhitney(xs, ys []float64) (*Result, error) {
+	xs, ys = present(xs), present(ys)
+	if len(xs) == 0 || len(ys) == 0 {
+		return nil, fmt.Errorf("Mann-Whitney U test requires values in each sample")
+	}
+	n1, n2 := float64(len(xs)), float64(len(ys))
+	n := n1 + n2
+	ranks := types.Rank(append(append([]float64(nil), xs...), ys...))
+	var r1 float64
+	for _, rank := range ranks[:len(xs)] {
+		r1 += rank
+	}
+	u := r1 - n1*(n1+1)/2
+	// Ties reduce the variance of U
+	counts := make(map[float64]float64)
+	for _, value := range append(append([]float64(nil), xs...), ys...) {
+		counts[value]++
+	}
+	var ties float64
+	for _, t := range counts {
+		ties += t*t*t - t
+	}
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return nil, fmt.Errorf("Samples have no variance")
+	}
+	z := math.Max(math.Abs(u-n1*n2/2)-0.5, 0) / sigma
+	return &Result{
+		Test:       "mann-whitney",
+		Statistic:  u,
+		PValue:     2 * (1 - distuv.Normal{Mu: 0, Sigma: 1}.CDF(z)),
+		Effect:     2*u/(n1*n2) - 1,
+		EffectName: "rank biserial",
+		N1:         len(xs),
+		N2:         len(ys),
+	}, nil
+}
+
+// KolmogorovSmirnov compares the distributions of two
+// samples by the largest distance D between their
+// empirical distribution functions with the asymptotic
+// distribution of D. The effect is D.
+func KolmogorovSmirnov(xs, ys []float64) (*Result, error) {
+	xs, ys = present(xs), present(ys)
+	if len(xs) == 0 || len(ys) == 0 {
+		return nil, fmt.Errorf("Kolmogorov-Smirnov test requires values in each sample")
+	}
+	sort.Float64s(xs)
+	sort.Float64s(ys)
+	n1, n2 := float64(len(xs)), float64(len(ys))
+	var d float64
+	for i, j := 0, 0; i < len(xs) && j < len(ys); {
+		value := math.Min(xs[i], ys[j])
+		for i < len(xs) && xs[i] == value {
+			i++
+		}
+		for j < len(ys) && ys[j] == value {
+			j++
+		}
+		d = math.Max(d, math.Abs(float64(i)/n1-float64(j)/n2))
+	}
+	en := math.Sqrt(n1 * n2 / (n1 + n2))
+	lambda := (en + 0.12 + 0.11/en) * d
+	// Kolmogorov distribution survival function
+	var p float64
+	for k := 1.0; k <= 100; k++ {
+		p += 2 * math.Pow(-1, k-1) * math.Exp(-2*k*k*lambda*lambda)
+	}
+	if lambda < 0.2 { // Series does not converge near zero
+		p = 1
+	}
+	return &Result{
+		Test:       "ks",
+		Statistic:  d,
+		PValue:     math.Max(0, math.Min(1, p)),
+		Effect:     d,
+		EffectName: "D",
+		N1:         len(xs),
+		N2:         len(ys),
+	}, nil
+}
+
+// ChiSquare tests whether two samples of categorical
+// values such as enums have the same frequency of each
+// value. The effect is Cramér's V.
+func ChiSquare(xs, ys []float64) (*Result, error) {
+	xs, ys = present(xs), present(ys)
+	if len(xs) == 0 || len(ys) == 0 {
+		return nil, fmt.Errorf("Chi-square test requires values in each sample")
+	}
+	observed := make(map[float64][2]float64)
+	for i, sample := range [][]float64{xs, ys} {
+		for _, value := range sample {
+			counts := observed[value]
+			counts[i]++
+			observed[value] = counts
+		}
+	}
+	if len(observed) < 2 {
+		return nil, fmt.Errorf("Chi-square test requires at least two categories")
+	}
+	n := float64(len(xs) + len(ys))
+	totals := [2]float64{float64(len(xs)), float64(len(ys))}
+	var statistic float64
+	for _, counts := range observed {
+		for i := range counts {
+			expected := totals[i] * (counts[0] + counts[1]) / n
+			statistic += math.Pow(counts[i]-expected, 2) / expected
+		}
+	}
+	df := float64(len(observed) - 1)
+	return &Result{
+		Test:       "chi-square",
+		Statistic:  statistic,
+		DF:         df,
+		PValue:     1 - distuv.ChiSquared{K: df}.CDF(statistic),
+		Effect:     math.Sqrt(statistic / n),
+		EffectName: "cramér's v",
+		N1:         len(xs),
+		N2:         len(ys),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package hypothesis
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+var (
+	before = []float64{1.2, 2.4, 3.1, 4.8, 5.0, 6.3, math.NaN()}
+	after  = []float64{2.9, 3.8, 4.4, 5.6, 6.9, 7.1, 8.0}
+)
+
+func TestWelch(t *testing.T) {
+	result, err := Welch(before, after)
+	assert.NoError(t, err)
+	assert.InDelta(t, -1.639381792, result.Statistic, 1e-8)
+	assert.InDelta(t, 10.708610411, result.DF, 1e-8)
+	assert.InDelta(t, 0.130148, result.PValue, 1e-5)
+	assert.InDelta(t, -0.911793989, result.Effect, 1e-8)
+	assert.Equal(t, 6, result.N1)
+	assert.Equal(t, 7, result.N2)
+	_, err = Welch([]float64{1}, after)
+	assert.Error(t, err)
+	_, err = Welch([]float64{1, 1}, []float64{2, 2})
+	assert.Error(t, err)
+}
+
+func TestPaired(t *testing.T) {
+	result, err := Paired([]float64{1, 2, 3, 4}, []float64{1.5, 2.1, 3.9, math.NaN()})
+	assert.NoError(t, err)
+	assert.InDelta(t, -2.165063509, result.Statistic, 1e-8)
+	assert.Equal(t, 2.0, result.DF)
+	// With 2 degrees of freedom p = 1 - |t| / sqrt(2 + t²)
+	assert.InDelta(t, 0.162781642, result.PValue, 1e-8)
+	assert.InDelta(t, -1.25, result.Effect, 1e-8)
+	_, err = Paired([]float64{1, 2}, []float64{1})
+	assert.Error(t, err)
+}
+
+func TestMannWhitney(t *testing.T) {
+	result, err := MannWhitney(before, after)
+	assert.NoError(t, err)
+	assert.Equal(t, 11.0, result.Statistic)
+	assert.InDelta(t, 0.174735823, result.PValue, 1e-8)
+	assert.InDelta(t, -0.476190476, result.Effect, 1e-8)
+	// Ties lower the variance of U
+	result, err = MannWhitney([]float64{1, 1, 2, 2}, []float64{2, 3, 3, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, result.Statistic)
+	_, err = MannWhitney([]float64{}, after)
+	assert.Error(t, err)
+}
+
+func TestKolmogorovSmirnov(t *testing.T) {
+	result, err := KolmogorovSmirnov(before, after)
+	assert.NoError(t, err)
+	assert.InDelta(t, 3/7.0, result.Statistic, 1e-12)
+	assert.InDelta(t, 0.468385022, result.PValue, 1e-8)
+	result, err = KolmogorovSmirnov(after, after)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, result.Statistic)
+	assert.Equal(t, 1.0, result.PValue)
+}
+
+func TestChiSquare(t *testing.T) {
+	xs, ys := make([]float64, 0), make([]float64, 0)
+	for category, counts := range [][2]int{{10, 20}, {20, 20}, {30, 10}} {
+		for i := 0; i < counts[0]; i++ {
+			xs = append(xs, float64(category))
+		}
+		for i := 0; i < counts[1]; i++ {
+			ys = append(ys, float64(category))
+		}
+	}
+	result, err := ChiSquare(xs, ys)
+	assert.NoError(t, err)
+	assert.InDelta(t, 12.527777778, result.Statistic, 1e-8)
+	assert.Equal(t, 2.0, result.DF)
+	// With 2 degrees of freedom p = exp(-x / 2)
+	assert.InDelta(t, 0.001903828, result.PValue, 1e-8)
+	assert.InDelta(t, 0.337474279, result.Effect, 1e-8)
+	_, err = ChiSquare([]float64{1}, []float64{1})
+	assert.Error(t, err)
+}
+
+func TestGetTest(t *testing.T) {
+	for name := range Tests {
+		_, err := GetTest(name)
+		assert.NoError(t, err)
+	}
+	_, err := GetTest("nope")
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ type CSV struct {
 	Columns []string
 	reader  *csv.Reader
 	rows    [][]string
+	lines   []int
 	index   int
 }
 
@@ -21,6 +22,13 @@ func (c *CSV) Row() ([]string, error) {
 	return row, nil
 }
 
+func (c CSV) Line() int {
+	if c.index == 0 {
+		return 0
+	}
+	return c.lines[c.index-1]
+}
+
 func (c CSV) Dims() (int, int) {
 	return len(c.rows), len(c.Columns)
 }
@@ -48,7 +56,9 @@ func NewCSV(reader io.Reader) (*CSV, error) {
 		if err != nil {
 			return nil, err
 		}
+		line, _ := c.reader.FieldPos(0)
 		c.rows = append(c.rows, row)
+		c.lines = append(c.lines, line)
 	}
 	return c, nil
 }
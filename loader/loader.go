@@ -18,20 +18,27 @@ var ErrUnequalValues = errors.New("unequal value size")
 type Rower interface {
 	Row() ([]string, error)
 	Dims() (int, int)
+	Line() int // Line number in the file of the last row returned
 }
 
 type Options struct {
-	Name    string
-	Path    string
-	Enc     string
-	Columns []string
-	Sheet   string // Sheet name (XLS)
-	Size    int64  // File Size (XLS)
-	Parsers map[int]parser.Parser
+	Name      string
+	Path      string
+	Enc       string
+	Columns   []string
+	Sheet     string // Sheet name (XLS)
+	AllSheets bool   // Load every sheet as a separate dataset (XLS)
+	Header    int    // Row containing column names, negative if none (XLS)
+	Size      int64  // File Size (XLS)
+	Parsers   parser.Parsers
+	Defaults  parser.Parsers // Parsers replaced by those of Parsers for the same column
+	Strict    bool           // Stop at the first value which cannot be parsed
+	Report    *Report        // Collects values which cannot be parsed if not Strict
 }
 
-// Rower returns a Rower based on the configured options
-func (opts *Options) Rower(fp *os.File) (Rower, error) {
+// defaults sets the dataset name and encoding
+// from the file path if they are not specified
+func (opts *Options) defaults() {
 	var split []string
 	if opts.Name == "" {
 		split = strings.Split(opts.Path, "/")
@@ -45,6 +52,11 @@ func (opts *Options) Rower(fp *os.File) (Rower, error) {
 		split = strings.Split(split[len(split)-1], ".")
 		opts.Enc = split[len(split)-1]
 	}
+}
+
+// Rower returns a Rower based on the configured options
+func (opts *Options) Rower(fp *os.File) (Rower, error) {
+	opts.defaults()
 	switch {
 	case opts.Enc == "csv":
 		csv, err := NewCSV(fp)
@@ -61,14 +73,21 @@ func (opts *Options) Rower(fp *os.File) (Rower, error) {
 			return nil, err
 		}
 		if len(opts.Columns) == 0 {
-			return nil, fmt.Errorf("Specify at least one column")
+			opts.Columns = xls.Columns
 		}
 		return xls, nil
 	}
-	panic(fmt.Sprintf("unknown encoding: %d", opts.Enc))
+	panic(fmt.Sprintf("unknown encoding: %s", opts.Enc))
 }
 
-func Matrix(rower Rower, parsers map[int]parser.Parser) (*mtx.Dense, error) {
+// Matrix reads each row from the Rower into a new
+// matrix. Values are parsed by the Parser configured
+// for their column or as a float. Empty values are
+// stored as NaN. Values which cannot be parsed cause
+// a ValueError to be returned if opts.Strict is set,
+// otherwise they are stored as NaN and recorded in
+// opts.Report if it is not nil.
+func Matrix(rower Rower, parsers map[int]parser.Parser, opts Options) (*mtx.Dense, error) {
 	r, c := rower.Dims()
 	mx := mtx.NewDense(r, c, nil)
 	for j := 0; j < r; j++ {
@@ -79,51 +98,149 @@ func Matrix(rower Rower, parsers map[int]parser.Parser) (*mtx.Dense, error) {
 		if err != nil {
 			return nil, err
 		}
+		if len(strs) > c {
+			return nil, ErrUnequalValues
+		}
 		row := make([]float64, c)
+		for i := range row {
+			row[i] = math.NaN()
+		}
 		for i, str := range strs {
+			if strings.TrimSpace(str) == "" {
+				continue // Missing value
+			}
+			var value float64
 			if parser, ok := parsers[i]; ok {
-				if value, err := parser.Parse(str); err == nil {
-					row[i] = value
-					continue
-				}
+				value, err = parser.Parse(str)
+			} else {
+				value, err = strconv.ParseFloat(strings.TrimSpace(str), 64)
 			}
-			if value, err := strconv.ParseFloat(str, 64); err == nil {
+			if err == nil {
 				row[i] = value
 				continue
 			}
-			row[i] = math.NaN()
+			verr := &ValueError{
+				Path:  opts.Path,
+				Line:  rower.Line(),
+				Value: str,
+				Err:   err,
+			}
+			if i < len(opts.Columns) {
+				verr.Column = opts.Columns[i]
+			}
+			if opts.Strict {
+				return nil, verr
+			}
+			if opts.Report != nil {
+				opts.Report.add(verr)
+			}
 		}
 		mx.SetRow(j, row)
 	}
 	return mx, nil
 }
 
+// Meta returns metadata for each column recorded
+// by the parsers applied to it or nil if there
+// is none
+func Meta(parsers map[int]parser.Parser, columns []string) []types.Meta {
+	var meta []types.Meta
+	for i, p := range parsers {
+		if describer, ok := p.(parser.Describer); ok && i < len(columns) {
+			if meta == nil {
+				meta = make([]types.Meta, len(columns))
+			}
+			describer.Describe(&meta[i])
+		}
+	}
+	return meta
+}
+
+func open(opts *Options) (*os.File, error) {
+	fp, err := os.Open(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := fp.Stat()
+	if err != nil {
+		fp.Close()
+		return nil, err
+	}
+	opts.Size = stats.Size()
+	return fp, nil
+}
+
+// ReadAll returns one dataset for each sheet
+// of an XLS file when AllSheets is set, each
+// named name_sheet. Otherwise it returns the
+// single dataset loaded by ReadPath.
+func ReadAll(opts Options) ([]*types.Dataset, error) {
+	opts.defaults()
+	if !opts.AllSheets || (opts.Enc != "xls" && opts.Enc != "xlsx") {
+		ds, err := ReadPath(opts)
+		if err != nil {
+			return nil, err
+		}
+		return []*types.Dataset{ds}, nil
+	}
+	fp, err := open(&opts)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	sheets, err := NewXLSSheets(fp, opts)
+	if err != nil {
+		return nil, err
+	}
+	datasets := make([]*types.Dataset, len(sheets))
+	for i, xls := range sheets {
+		parsers, err := parser.Override(xls.Columns, opts.Defaults, opts.Parsers)
+		if err != nil {
+			return nil, err
+		}
+		sheetOpts := opts
+		sheetOpts.Path = fmt.Sprintf("%s[%s]", opts.Path, xls.Name())
+		sheetOpts.Columns = xls.Columns
+		mx, err := Matrix(xls, parsers, sheetOpts)
+		if err != nil {
+			return nil, err
+		}
+		datasets[i] = &types.Dataset{
+			Name:    fmt.Sprintf("%s_%s", opts.Name, xls.Name()),
+			Columns: xls.Columns,
+			Meta:    Meta(parsers, xls.Columns),
+			Mtx:     mx,
+		}
+	}
+	return datasets, nil
+}
+
 func ReadPath(opts Options) (*types.Dataset, error) {
 	var (
 		rower Rower
 		mx    *mtx.Dense
 	)
-	fp, err := os.Open(opts.Path)
+	fp, err := open(&opts)
 	if err != nil {
 		return nil, err
 	}
-	stats, err := fp.Stat()
+	defer fp.Close()
+	rower, err = opts.Rower(fp)
 	if err != nil {
 		return nil, err
 	}
-	defer fp.Close()
-	opts.Size = stats.Size()
-	rower, err = opts.Rower(fp)
+	parsers, err := parser.Override(opts.Columns, opts.Defaults, opts.Parsers)
 	if err != nil {
 		return nil, err
 	}
-	mx, err = Matrix(rower, opts.Parsers)
+	mx, err = Matrix(rower, parsers, opts)
 	if err != nil {
 		return nil, err
 	}
 	return &types.Dataset{
 		Name:    opts.Name,
 		Columns: opts.Columns,
+		Meta:    Meta(parsers, opts.Columns),
 		Mtx:     mx,
 	}, nil
 }
@@ -0,0 +1,73 @@
+package loader
+
+import (
+	"github.com/kevinschoon/fit/parser"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+)
+
+func NewTestCSV(t *testing.T, data string) (string, func()) {
+	f, err := ioutil.TempFile("/tmp", "fit-test-")
+	assert.NoError(t, err)
+	_, err = f.WriteString(data)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+	path := f.Name() + ".csv"
+	assert.NoError(t, os.Rename(f.Name(), path))
+	return path, func() { os.Remove(path) }
+}
+
+func TestReadPathParsers(t *testing.T) {
+	path, cleanup := NewTestCSV(t, "date,active,value\n2001-01-01,yes,1.5\n2001-01-02,no,2.5\n")
+	defer cleanup()
+	parsers, err := parser.ParsersFromArgs([]string{"date,Time,2006-01-02", "active,Bool"})
+	assert.NoError(t, err)
+	ds, err := ReadPath(Options{Path: path, Parsers: parsers})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"date", "active", "value"}, ds.Columns)
+	assert.Equal(t, 978307200.0, ds.Mtx.At(0, 0))
+	assert.Equal(t, 1.0, ds.Mtx.At(0, 1))
+	assert.Equal(t, 0.0, ds.Mtx.At(1, 1))
+	assert.Equal(t, 2.5, ds.Mtx.At(1, 2))
+	// Parsers replace the Defaults of the same column
+	ds, err = ReadPath(Options{Path: path, Parsers: parser.Parsers{"active": parser.PercentParser{}}, Defaults: parsers})
+	assert.NoError(t, err)
+	assert.Equal(t, 978307200.0, ds.Mtx.At(0, 0))
+	assert.True(t, math.IsNaN(ds.Mtx.At(0, 1)))
+	parsers, err = parser.ParsersFromArgs([]string{"time,Time,2006-01-02"})
+	assert.NoError(t, err)
+	_, err = ReadPath(Options{Path: path, Parsers: parsers})
+	assert.EqualError(t, err, "Parser column not found: time")
+}
+
+func TestReadPathErrors(t *testing.T) {
+	path, cleanup := NewTestCSV(t, "time,value\n1,1.5\n2,fuu\n3,\n4,bar\n5,baz\n")
+	defer cleanup()
+	_, err := ReadPath(Options{Path: path, Strict: true})
+	assert.Error(t, err)
+	assert.IsType(t, &ValueError{}, err)
+	verr := err.(*ValueError)
+	assert.Equal(t, path, verr.Path)
+	assert.Equal(t, 3, verr.Line)
+	assert.Equal(t, "value", verr.Column)
+	assert.Equal(t, "fuu", verr.Value)
+	report := &Report{Max: 2}
+	ds, err := ReadPath(Options{Path: path, Report: report})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, ds.Len())
+	assert.True(t, math.IsNaN(ds.Mtx.At(1, 1)))
+	assert.True(t, math.IsNaN(ds.Mtx.At(2, 1)))
+	assert.Equal(t, 3, report.Total)
+	assert.Len(t, report.Errors, 2)
+	assert.Equal(t, 5, report.Errors[1].Line)
+	assert.Contains(t, report.String(), "... 1 more errors omitted")
+	parsers, err := parser.ParsersFromArgs([]string{"time,Time,2006"})
+	assert.NoError(t, err)
+	_, err = ReadPath(Options{Path: path, Parsers: parsers, Strict: true})
+	assert.Error(t, err)
+	assert.Equal(t, "time", err.(*ValueError).Column)
+	assert.Equal(t, 2, err.(*ValueError).Line)
+}
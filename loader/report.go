@@ -0,0 +1,51 @@
+package loader
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ValueError describes a value which
+// could not be parsed while loading
+type ValueError struct {
+	Path   string // Path of the file being loaded
+	Line   int    // Line (or row) number of the value
+	Column string // Name of the column
+	Value  string // Raw text of the value
+	Err    error  // Error returned by the parser
+}
+
+func (e ValueError) Error() string {
+	return fmt.Sprintf("%s:%d: column %q: cannot parse %q: %s", e.Path, e.Line, e.Column, e.Value, e.Err)
+}
+
+// Report collects the values which could
+// not be parsed when loading in lenient mode.
+// At most Max errors are retained while
+// Total counts every error encountered.
+type Report struct {
+	Max    int
+	Total  int
+	Errors []*ValueError
+}
+
+func (report *Report) add(err *ValueError) {
+	report.Total++
+	if report.Max <= 0 || len(report.Errors) < report.Max {
+		report.Errors = append(report.Errors, err)
+	}
+}
+
+// String returns each retained error on
+// a separate line followed by the number
+// of errors which were omitted
+func (report Report) String() string {
+	buf := bytes.NewBuffer(nil)
+	for _, err := range report.Errors {
+		fmt.Fprintln(buf, err.Error())
+	}
+	if omitted := report.Total - len(report.Errors); omitted > 0 {
+		fmt.Fprintf(buf, "... %d more errors omitted\n", omitted)
+	}
+	return buf.String()
+}
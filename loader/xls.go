@@ -2,26 +2,44 @@ package loader
 
 import (
 	"errors"
+	"fmt"
 	"github.com/tealeg/xlsx"
 	"io"
+	"strconv"
+	"strings"
 )
 
 var InvalidXLS = errors.New("Invalid XLS")
 
 type XLS struct {
-	file    *xlsx.File
-	sheet   *xlsx.Sheet
-	index   int
-	columns []string
+	Columns  []string
+	sheet    *xlsx.Sheet
+	start    int // First row containing data
+	index    int
+	date1904 bool
+}
+
+// Name returns the name of the underlying sheet
+func (x XLS) Name() string {
+	return x.sheet.Name
 }
 
 func (x *XLS) Row() ([]string, error) {
-	if x.index > x.sheet.MaxRow {
+	if x.index >= x.sheet.MaxRow {
 		return nil, io.EOF
 	}
-	values := make([]string, len(x.columns))
+	values := make([]string, len(x.Columns))
 	for i := 0; i < len(values); i++ {
-		if str, err := x.sheet.Cell(x.index, i).String(); err == nil {
+		cell := x.sheet.Cell(x.index, i)
+		// Dates are stored as serial numbers and
+		// are loaded as Unix epoch time
+		if isDateFormat(cell.GetNumberFormat()) {
+			if serial, err := cell.Float(); err == nil {
+				values[i] = strconv.FormatInt(xlsx.TimeFromExcelTime(serial, x.date1904).Unix(), 10)
+				continue
+			}
+		}
+		if str, err := cell.String(); err == nil {
 			values[i] = str
 		}
 	}
@@ -29,29 +47,130 @@ func (x *XLS) Row() ([]string, error) {
 	return values, nil
 }
 
+// Line returns the spreadsheet row number
+// of the last row returned
+func (x XLS) Line() int {
+	return x.index
+}
+
 func (x XLS) Dims() (int, int) {
-	return x.sheet.MaxRow, len(x.columns)
+	rows := x.sheet.MaxRow - x.start
+	if rows < 0 {
+		rows = 0
+	}
+	return rows, len(x.Columns)
+}
+
+func newXLS(f *xlsx.File, sheet *xlsx.Sheet, opts Options) (*XLS, error) {
+	xls := &XLS{
+		Columns:  opts.Columns,
+		sheet:    sheet,
+		date1904: f.Date1904,
+	}
+	// A negative header indicates the sheet
+	// contains no row of column names
+	if opts.Header >= 0 {
+		if opts.Header >= sheet.MaxRow {
+			return nil, fmt.Errorf("Header row %d not found in sheet %s", opts.Header, sheet.Name)
+		}
+		xls.start = opts.Header + 1
+		if len(xls.Columns) == 0 {
+			for i := 0; i < sheet.MaxCol; i++ {
+				str, _ := sheet.Cell(opts.Header, i).String()
+				xls.Columns = append(xls.Columns, str)
+			}
+			// Ignore empty trailing cells
+			for len(xls.Columns) > 0 && xls.Columns[len(xls.Columns)-1] == "" {
+				xls.Columns = xls.Columns[:len(xls.Columns)-1]
+			}
+		}
+	}
+	if len(xls.Columns) == 0 {
+		return nil, fmt.Errorf("Specify at least one column")
+	}
+	xls.index = xls.start
+	return xls, nil
 }
 
+// NewXLS returns an XLS Rower for the sheet
+// configured in Options or the first sheet
+// if none is specified
 func NewXLS(reader io.ReaderAt, opts Options) (*XLS, error) {
 	f, err := xlsx.OpenReaderAt(reader, opts.Size)
 	if err != nil {
 		return nil, err
 	}
-	xls := &XLS{
-		columns: opts.Columns,
-	}
 	if opts.Sheet != "" {
 		if sheet, ok := f.Sheet[opts.Sheet]; ok {
-			xls.sheet = sheet
-		} else {
-			return nil, InvalidXLS
+			return newXLS(f, sheet, opts)
 		}
-	} else {
-		if len(f.Sheets) == 0 {
-			return nil, InvalidXLS
+		return nil, InvalidXLS
+	}
+	if len(f.Sheets) == 0 {
+		return nil, InvalidXLS
+	}
+	return newXLS(f, f.Sheets[0], opts)
+}
+
+// NewXLSSheets returns an XLS Rower for
+// every sheet within the file
+func NewXLSSheets(reader io.ReaderAt, opts Options) ([]*XLS, error) {
+	f, err := xlsx.OpenReaderAt(reader, opts.Size)
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Sheets) == 0 {
+		return nil, InvalidXLS
+	}
+	sheets := make([]*XLS, len(f.Sheets))
+	for i, sheet := range f.Sheets {
+		xls, err := newXLS(f, sheet, opts)
+		if err != nil {
+			return nil, err
 		}
-		xls.sheet = f.Sheets[0]
+		sheets[i] = xls
 	}
-	return xls, nil
+	return sheets, nil
+}
+
+// isDateFormat reports if an Excel number
+// format describes a date or time value.
+// Quoted text, escaped characters, and
+// bracketed sections such as colours and
+// locales are ignored.
+func isDateFormat(format string) bool {
+	format = strings.ToLower(format)
+	var (
+		quoted  bool
+		bracket bool
+		escaped bool
+		section string
+	)
+	for _, char := range format {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted:
+			quoted = char != '"'
+		case bracket && char == ']':
+			// Elapsed time such as [h]:mm:ss
+			if section != "" && strings.Trim(section, string(section[0])) == "" &&
+				strings.ContainsRune("hms", rune(section[0])) {
+				return true
+			}
+			bracket = false
+		case bracket:
+			section += string(char)
+		case char == '\\':
+			escaped = true
+		case char == '"':
+			quoted = true
+		case char == '[':
+			bracket = true
+			section = ""
+		case strings.ContainsRune("ymdhs", char):
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,111 @@
+package loader
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"testing"
+)
+
+const Lakes string = "testdata/lakes.xlsx"
+
+func NewTestXLS(t *testing.T, opts Options) (*XLS, error) {
+	fp, err := os.Open(Lakes)
+	assert.NoError(t, err)
+	defer fp.Close()
+	stats, err := fp.Stat()
+	assert.NoError(t, err)
+	opts.Size = stats.Size()
+	return NewXLS(fp, opts)
+}
+
+func TestXLSHeader(t *testing.T) {
+	xls, err := NewTestXLS(t, Options{Header: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "Huron", xls.Name())
+	assert.Equal(t, []string{"year", "level"}, xls.Columns)
+	rows, cols := xls.Dims()
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, 2, cols)
+	values, err := xls.Row()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-2997907200", "580.38"}, values)
+	assert.Equal(t, 3, xls.Line())
+	xls, err = NewTestXLS(t, Options{Header: -1, Columns: []string{"year", "level"}})
+	assert.NoError(t, err)
+	rows, _ = xls.Dims()
+	assert.Equal(t, 5, rows)
+	values, err = xls.Row()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Lake levels in feet", ""}, values)
+	_, err = NewTestXLS(t, Options{Header: -1})
+	assert.EqualError(t, err, "Specify at least one column")
+	_, err = NewTestXLS(t, Options{Header: 5})
+	assert.EqualError(t, err, "Header row 5 not found in sheet Huron")
+	xls, err = NewTestXLS(t, Options{Header: 1, Sheet: "Erie"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Erie", xls.Name())
+	_, err = NewTestXLS(t, Options{Header: 1, Sheet: "Ontario"})
+	assert.Equal(t, InvalidXLS, err)
+}
+
+func TestXLSLastRow(t *testing.T) {
+	xls, err := NewTestXLS(t, Options{Header: 1, Sheet: "Erie"})
+	assert.NoError(t, err)
+	values, err := xls.Row()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-2997907200", "571.25"}, values)
+	values, err = xls.Row()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-2966371200", "571.5"}, values)
+	// The last row is not followed by an empty row
+	_, err = xls.Row()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadAllSheets(t *testing.T) {
+	datasets, err := ReadAll(Options{Path: Lakes, AllSheets: true, Header: 1})
+	assert.NoError(t, err)
+	assert.Len(t, datasets, 2)
+	assert.Equal(t, "lakes_Huron", datasets[0].Name)
+	assert.Equal(t, "lakes_Erie", datasets[1].Name)
+	assert.Equal(t, []string{"year", "level"}, datasets[1].Columns)
+	assert.Equal(t, 3, datasets[0].Len())
+	assert.Equal(t, 2, datasets[1].Len())
+	assert.Equal(t, 571.5, datasets[1].Mtx.At(1, 1))
+	datasets, err = ReadAll(Options{Path: Lakes, Header: 1})
+	assert.NoError(t, err)
+	assert.Len(t, datasets, 1)
+	assert.Equal(t, "lakes", datasets[0].Name)
+	assert.Equal(t, 3, datasets[0].Len())
+}
+
+func TestIsDateFormat(t *testing.T) {
+	for _, format := range []string{
+		"yyyy-mm-dd",
+		"m/d/yy",
+		"d-mmm-yy",
+		"h:mm AM/PM",
+		"mm:ss.0",
+		"[h]:mm:ss",
+		"[$-409]mmmm d, yyyy",
+		"[Red]dd/mm/yyyy",
+	} {
+		assert.True(t, isDateFormat(format), format)
+	}
+	for _, format := range []string{
+		"",
+		"General",
+		"0.00",
+		"#,##0",
+		"0.00E+00",
+		"@",
+		`"Days "0`,
+		`0\s`,
+		"[Red]0.00",
+		"[Magenta]#,##0",
+		"[$USD]#,##0.00",
+	} {
+		assert.False(t, isDateFormat(format), format)
+	}
+}
@@ -1,7 +1,13 @@
 package parser
 
 import (
+	"bufio"
 	"fmt"
+	"github.com/kevinschoon/fit/types"
+	"io"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -11,42 +17,447 @@ type Parser interface {
 	Parse(string) (float64, error)
 }
 
-// Parsers loads Parser types from the array of strings
+// Describer is implemented by Parsers which
+// record metadata about the column they parse
+type Describer interface {
+	Describe(*types.Meta)
+}
+
+// Parsers maps a column, referenced by either
+// its name or its index, to a Parser
+type Parsers map[string]Parser
+
+// Resolve returns each Parser keyed by the position
+// of its column. References which match a column
+// name are resolved by name, otherwise they must
+// be a valid column index. Two references to the
+// same column are an error.
+func (parsers Parsers) Resolve(columns []string) (map[int]Parser, error) {
+	keys := make([]string, 0, len(parsers))
+	for key := range parsers {
+		keys = append(keys, key)
+	}
+	// Sort references so errors are reported consistently
+	sort.Strings(keys)
+	resolved := make(map[int]Parser)
+	references := make(map[int]string)
+	for _, key := range keys {
+		index := -1
+		for i, column := range columns {
+			if key == column {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			if i, err := strconv.ParseInt(key, 0, 64); err == nil && i >= 0 && int(i) < len(columns) {
+				index = int(i)
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("Parser column not found: %s", key)
+		}
+		if previous, ok := references[index]; ok {
+			return nil, fmt.Errorf("Parsers %s and %s reference the same column", previous, key)
+		}
+		references[index] = key
+		resolved[index] = parsers[key]
+	}
+	return resolved, nil
+}
+
+// Override resolves defaults and parsers against
+// columns, a Parser of parsers replacing the one
+// of defaults which references the same column
+func Override(columns []string, defaults, parsers Parsers) (map[int]Parser, error) {
+	resolved, err := defaults.Resolve(columns)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := parsers.Resolve(columns)
+	if err != nil {
+		return nil, err
+	}
+	for index, parser := range overrides {
+		resolved[index] = parser
+	}
+	return resolved, nil
+}
+
+// ParsersFromArgs loads Parser types from the array of strings
 // Example:
 // 1,Time,2006-01-02
-// ^-^----^----index(int),name(string),format(string)
-func ParsersFromArgs(args []string) (map[int]Parser, error) {
-	parsers := make(map[int]Parser)
+// ^-^----^----column(name or index),name(string),format(string)
+//
+// The Time parser optionally accepts an IANA location
+// and a precision (s, ms, us or ns) separated by |
+// time,Time,2006-01-02 15:04:05.000|Europe/Berlin|ms
+//
+// Parsers which require no configuration may omit
+// the final argument:
+// 2,Bool
+// 3,Percent
+// 4,Currency
+// status,Enum,low=0|mid=1|high=2
+// temp,Unit,degF->degC
+// latency,Regex,latency: ([0-9.]+)ms
+//
+// The Regex parser extracts the group named value,
+// (?P<value>...), or the first group. A trailing |N
+// selects group N instead:
+// reading,Regex,(\w+)=([0-9.]+)|2
+//
+// Parsers configured with the same reference are
+// chained into a Pipeline in the order they are given,
+// referencing a column by both name and index is an
+// error:
+// ts,Trim
+// ts,Regex,^at (.*)$
+// ts,Time,2006-01-02
+//
+// Additional parsers may be made available with Register.
+func ParsersFromArgs(args []string) (Parsers, error) {
+	parsers := make(Parsers)
 	for _, arg := range args {
-		split := strings.Split(arg, ",")
-		if len(split) < 3 {
+		split := strings.SplitN(arg, ",", 3)
+		if len(split) < 2 || split[0] == "" {
 			return nil, fmt.Errorf("Bad parser opts: %s", arg)
 		}
-		index, err := strconv.ParseInt(split[0], 0, 64)
+		column := split[0]
+		var opts string
+		if len(split) == 3 {
+			opts = split[2]
+		}
+		parser, err := New(split[1], opts)
 		if err != nil {
-			return nil, fmt.Errorf("Bad parser opts: %s", arg)
+			return nil, err
 		}
-		switch split[1] {
-		case "Time":
-			if len(split) != 3 {
-				return nil, fmt.Errorf("Bad parser opts: %s", arg)
+		// Chain the parser to any previously
+		// configured for the same column
+		if previous, ok := parsers[column]; ok {
+			pipeline, err := Chain(previous, parser)
+			if err != nil {
+				return nil, fmt.Errorf("Bad parser opts: %s: %s", arg, err)
 			}
-			parsers[int(index)] = TimeParser{Format: split[2]}
-		default:
-			return nil, fmt.Errorf("Unknown parser: %s", split[1])
+			parser = pipeline
 		}
+		parsers[column] = parser
 	}
 	return parsers, nil
 }
 
+// ParsersFromReader loads Parser types with one
+// argument per line in the same format as
+// ParsersFromArgs. Blank lines and lines
+// beginning with # are ignored.
+func ParsersFromReader(reader io.Reader) (Parsers, error) {
+	args := make([]string, 0)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		args = append(args, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ParsersFromArgs(args)
+}
+
+// ParsersFromFile loads Parser types from the file at path
+func ParsersFromFile(path string) (Parsers, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return ParsersFromReader(fp)
+}
+
+// TimeParser parses times in Location, or UTC
+// if no Location is set, and stores them as the
+// time elapsed since the Unix epoch in units of
+// Precision
 type TimeParser struct {
-	Format string
+	Format    string
+	Location  *time.Location
+	Precision types.Precision
 }
 
 func (t TimeParser) Parse(v string) (float64, error) {
-	parsed, err := time.Parse(t.Format, v)
+	location := t.Location
+	if location == nil {
+		location = time.UTC
+	}
+	parsed, err := time.ParseInLocation(t.Format, v, location)
+	if err != nil {
+		return 0.0, err
+	}
+	return t.Precision.Value(parsed), nil
+}
+
+func (t TimeParser) Describe(meta *types.Meta) {
+	meta.Precision = t.Precision
+	if meta.Precision == "" {
+		meta.Precision = types.Seconds
+	}
+}
+
+// NewTimeParser returns a TimeParser from a
+// string in the format FORMAT[|LOCATION[|PRECISION]]
+func NewTimeParser(arg string) (TimeParser, error) {
+	split := strings.Split(arg, "|")
+	parser := TimeParser{Format: split[0]}
+	if parser.Format == "" || len(split) > 3 {
+		return parser, fmt.Errorf("Bad time opts: %s", arg)
+	}
+	if len(split) > 1 && split[1] != "" {
+		location, err := time.LoadLocation(split[1])
+		if err != nil {
+			return parser, err
+		}
+		parser.Location = location
+	}
+	if len(split) > 2 && split[2] != "" {
+		precision, err := types.ParsePrecision(split[2])
+		if err != nil {
+			return parser, err
+		}
+		parser.Precision = precision
+	}
+	return parser, nil
+}
+
+// BoolParser stores common boolean values
+// such as true/false, yes/no and on/off
+// as 1 or 0
+type BoolParser struct{}
+
+func (b BoolParser) Parse(v string) (float64, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "t", "yes", "y", "on", "1":
+		return 1.0, nil
+	case "false", "f", "no", "n", "off", "0":
+		return 0.0, nil
+	}
+	return 0.0, fmt.Errorf("Bad boolean value: %s", v)
+}
+
+// PercentParser stores percentages such
+// as 12.5% as a fraction (0.125)
+type PercentParser struct{}
+
+func (p PercentParser) Parse(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	if !strings.HasSuffix(v, "%") {
+		return 0.0, fmt.Errorf("Bad percent value: %s", v)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(v, "%")), 64)
 	if err != nil {
 		return 0.0, err
 	}
-	return float64(parsed.Unix()), nil
+	return value / 100, nil
+}
+
+// CurrencyParser removes currency symbols and
+// thousands separators from numbers such as
+// $1,234.50. Decimal is the character separating
+// the fractional part, defaulting to "." in which
+// case "," is treated as the thousands separator
+// and vice versa.
+type CurrencyParser struct {
+	Decimal string
+}
+
+func (c CurrencyParser) Parse(v string) (float64, error) {
+	decimal := c.Decimal
+	if decimal == "" {
+		decimal = "."
+	}
+	thousands := ","
+	if decimal == "," {
+		thousands = "."
+	}
+	v = strings.TrimSpace(v)
+	negative := strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")")
+	v = strings.Trim(v, "()")
+	v = strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(thousands, r), r == ' ':
+			return -1
+		case strings.ContainsRune("$€£¥₹", r):
+			return -1
+		case strings.ContainsRune(decimal, r):
+			return '.'
+		}
+		return r
+	}, v)
+	value, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0.0, err
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+// NewCurrencyParser returns a CurrencyParser with
+// the decimal separator "." or ","
+func NewCurrencyParser(decimal string) (CurrencyParser, error) {
+	switch decimal {
+	case "", ".", ",":
+		return CurrencyParser{Decimal: decimal}, nil
+	}
+	return CurrencyParser{}, fmt.Errorf("Bad decimal separator: %s", decimal)
+}
+
+// EnumParser maps a fixed set of string values
+// to numbers
+type EnumParser struct {
+	Values map[string]float64
+}
+
+func (e EnumParser) Parse(v string) (float64, error) {
+	if value, ok := e.Values[strings.TrimSpace(v)]; ok {
+		return value, nil
+	}
+	return 0.0, fmt.Errorf("Unknown enum value: %s", v)
+}
+
+// NewEnumParser returns an EnumParser from a string
+// of pipe separated name=value pairs. Names without
+// a value are assigned their position.
+//
+// low=0|mid=1|high=2
+// low|mid|high
+func NewEnumParser(arg string) (EnumParser, error) {
+	enum := EnumParser{Values: make(map[string]float64)}
+	if arg == "" {
+		return enum, fmt.Errorf("Bad enum opts: %s", arg)
+	}
+	for i, pair := range strings.Split(arg, "|") {
+		split := strings.SplitN(pair, "=", 2)
+		name := strings.TrimSpace(split[0])
+		if name == "" {
+			return enum, fmt.Errorf("Bad enum opts: %s", arg)
+		}
+		value := float64(i)
+		if len(split) == 2 {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+			if err != nil {
+				return enum, fmt.Errorf("Bad enum opts: %s", arg)
+			}
+			value = parsed
+		}
+		enum.Values[name] = value
+	}
+	return enum, nil
+}
+
+// UnitParser converts values from one unit
+// of measurement to another and records the
+// resulting unit of the column
+type UnitParser struct {
+	From types.Unit
+	To   types.Unit
+}
+
+func (u UnitParser) Parse(v string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0.0, err
+	}
+	return u.From.Convert(value, u.To)
+}
+
+func (u UnitParser) Describe(meta *types.Meta) {
+	meta.Unit = u.To.Name
+}
+
+// NewUnitParser returns a UnitParser from a string
+// in the format FROM->TO. If only a single unit is
+// given values are recorded in that unit unchanged.
+//
+// degF->degC
+// km
+func NewUnitParser(arg string) (UnitParser, error) {
+	split := strings.Split(arg, "->")
+	if len(split) > 2 {
+		return UnitParser{}, fmt.Errorf("Bad unit opts: %s", arg)
+	}
+	from, err := types.GetUnit(strings.TrimSpace(split[0]))
+	if err != nil {
+		return UnitParser{}, err
+	}
+	parser := UnitParser{From: from, To: from}
+	if len(split) == 2 {
+		to, err := types.GetUnit(strings.TrimSpace(split[1]))
+		if err != nil {
+			return UnitParser{}, err
+		}
+		if from.Kind != to.Kind {
+			return UnitParser{}, fmt.Errorf("Cannot convert %s to %s", from.Name, to.Name)
+		}
+		parser.To = to
+	}
+	return parser, nil
+}
+
+// RegexParser extracts text matching a regular
+// expression and parses it as a float. When used
+// in a Pipeline the extracted text is passed to
+// the next Parser. Group is extracted, which
+// defaults to the group named "value" if it
+// exists, otherwise the first group or the entire
+// match if there are no groups.
+type RegexParser struct {
+	Regexp *regexp.Regexp
+	Group  int
+}
+
+func (r RegexParser) Transform(v string) (string, error) {
+	match := r.Regexp.FindStringSubmatch(v)
+	if match == nil || r.Group >= len(match) {
+		return "", fmt.Errorf("Value %q does not match %s", v, r.Regexp)
+	}
+	return match[r.Group], nil
+}
+
+func (r RegexParser) Parse(v string) (float64, error) {
+	str, err := r.Transform(v)
+	if err != nil {
+		return 0.0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(str), 64)
+}
+
+// NewRegexParser returns a RegexParser for the pattern.
+// A pattern ending in |N extracts group N when the
+// pattern has at least N groups, an alternative
+// ending in digits may be written as (?:a|1).
+func NewRegexParser(pattern string) (RegexParser, error) {
+	if i := strings.LastIndex(pattern, "|"); i >= 0 {
+		if group, err := strconv.Atoi(pattern[i+1:]); err == nil && group >= 0 {
+			if re, err := regexp.Compile(pattern[:i]); err == nil && group <= re.NumSubexp() {
+				return RegexParser{Regexp: re, Group: group}, nil
+			}
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return RegexParser{}, err
+	}
+	parser := RegexParser{Regexp: re}
+	if re.NumSubexp() > 0 {
+		parser.Group = 1
+	}
+	for i, name := range re.SubexpNames() {
+		if name == "value" {
+			parser.Group = i
+		}
+	}
+	return parser, nil
 }
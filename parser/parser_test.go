@@ -0,0 +1,246 @@
+package parser
+
+import (
+	"github.com/kevinschoon/fit/types"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParsersFromArgs(t *testing.T) {
+	parsers, err := ParsersFromArgs([]string{
+		"0,Time,Jan 2, 2006",
+		"1,Bool",
+		"2,Percent",
+		"3,Currency",
+		"4,Currency,,",
+		"5,Enum,low=0|mid=1|high=2",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, parsers, 6)
+	assert.Equal(t, TimeParser{Format: "Jan 2, 2006"}, parsers["0"])
+	assert.IsType(t, BoolParser{}, parsers["1"])
+	assert.IsType(t, PercentParser{}, parsers["2"])
+	assert.Equal(t, CurrencyParser{}, parsers["3"])
+	assert.Equal(t, CurrencyParser{Decimal: ","}, parsers["4"])
+	assert.IsType(t, EnumParser{}, parsers["5"])
+	_, err = ParsersFromArgs([]string{"0,Time"})
+	assert.Error(t, err)
+	_, err = ParsersFromArgs([]string{"0,Enum"})
+	assert.Error(t, err)
+	_, err = ParsersFromArgs([]string{"0,Fuu,bar"})
+	assert.Error(t, err)
+	_, err = ParsersFromArgs([]string{",Bool"})
+	assert.Error(t, err)
+}
+
+func TestResolve(t *testing.T) {
+	parsers, err := ParsersFromArgs([]string{
+		"time,Time,2006",
+		"2,Percent",
+		"active,Bool",
+	})
+	assert.NoError(t, err)
+	resolved, err := parsers.Resolve([]string{"", "time", "change", "active"})
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 3)
+	assert.IsType(t, TimeParser{}, resolved[1])
+	assert.IsType(t, PercentParser{}, resolved[2])
+	assert.IsType(t, BoolParser{}, resolved[3])
+	_, err = parsers.Resolve([]string{"time", "change", "status"})
+	assert.EqualError(t, err, "Parser column not found: active")
+	_, err = Parsers{"9": BoolParser{}}.Resolve([]string{"time"})
+	assert.Error(t, err)
+	_, err = Parsers{"0": BoolParser{}, "time": PercentParser{}}.Resolve([]string{"time"})
+	assert.EqualError(t, err, "Parsers 0 and time reference the same column")
+}
+
+func TestOverride(t *testing.T) {
+	columns := []string{"time", "change", "active"}
+	defaults := Parsers{"0": TimeParser{}, "change": BoolParser{}}
+	resolved, err := Override(columns, defaults, Parsers{"time": PercentParser{}, "2": BoolParser{}})
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 3)
+	assert.IsType(t, PercentParser{}, resolved[0])
+	assert.IsType(t, BoolParser{}, resolved[1])
+	assert.IsType(t, BoolParser{}, resolved[2])
+	_, err = Override(columns, defaults, Parsers{"status": BoolParser{}})
+	assert.EqualError(t, err, "Parser column not found: status")
+}
+
+func TestParsersFromReader(t *testing.T) {
+	parsers, err := ParsersFromReader(strings.NewReader(`
+# Vendor A
+time,Time,2006-01-02 15:04:05
+
+status,Enum,ok|warn|error
+`))
+	assert.NoError(t, err)
+	assert.Len(t, parsers, 2)
+	assert.Equal(t, TimeParser{Format: "2006-01-02 15:04:05"}, parsers["time"])
+	assert.IsType(t, EnumParser{}, parsers["status"])
+}
+
+func TestBoolParser(t *testing.T) {
+	for _, v := range []string{"true", "TRUE", "yes", "Y", " on ", "1"} {
+		value, err := BoolParser{}.Parse(v)
+		assert.NoError(t, err)
+		assert.Equal(t, 1.0, value)
+	}
+	for _, v := range []string{"false", "No", "n", "off", "0"} {
+		value, err := BoolParser{}.Parse(v)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, value)
+	}
+	_, err := BoolParser{}.Parse("maybe")
+	assert.Error(t, err)
+}
+
+func TestPercentParser(t *testing.T) {
+	value, err := PercentParser{}.Parse("12.5%")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.125, value)
+	value, err = PercentParser{}.Parse("-50 %")
+	assert.NoError(t, err)
+	assert.Equal(t, -0.5, value)
+	_, err = PercentParser{}.Parse("12.5")
+	assert.Error(t, err)
+}
+
+func TestCurrencyParser(t *testing.T) {
+	value, err := CurrencyParser{}.Parse("$1,234.50")
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.5, value)
+	value, err = CurrencyParser{}.Parse("($1,000)")
+	assert.NoError(t, err)
+	assert.Equal(t, -1000.0, value)
+	value, err = CurrencyParser{Decimal: ","}.Parse("€1.234,50")
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.5, value)
+	_, err = CurrencyParser{}.Parse("fuu")
+	assert.Error(t, err)
+	for _, decimal := range []string{"", ".", ","} {
+		_, err = NewCurrencyParser(decimal)
+		assert.NoError(t, err)
+	}
+	_, err = NewCurrencyParser("abc")
+	assert.EqualError(t, err, "Bad decimal separator: abc")
+	_, err = ParsersFromArgs([]string{"3,Currency,abc"})
+	assert.Error(t, err)
+}
+
+func TestEnumParser(t *testing.T) {
+	enum, err := NewEnumParser("low=0|mid=1|high=2")
+	assert.NoError(t, err)
+	value, err := enum.Parse("high")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	_, err = enum.Parse("extreme")
+	assert.Error(t, err)
+	enum, err = NewEnumParser("ok|warn|error")
+	assert.NoError(t, err)
+	value, err = enum.Parse("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, value)
+	_, err = NewEnumParser("low=x")
+	assert.Error(t, err)
+}
+
+func TestTimeParser(t *testing.T) {
+	parser, err := NewTimeParser("2006-01-02 15:04:05.000|America/New_York|ms")
+	assert.NoError(t, err)
+	assert.Equal(t, types.Milliseconds, parser.Precision)
+	// EDT is four hours behind UTC
+	value, err := parser.Parse("2016-07-01 12:00:00.250")
+	assert.NoError(t, err)
+	assert.Equal(t, 1467388800250.0, value)
+	// EST is five hours behind UTC
+	value, err = parser.Parse("2016-12-01 12:00:00.000")
+	assert.NoError(t, err)
+	assert.Equal(t, 1480611600000.0, value)
+	meta := &types.Meta{}
+	parser.Describe(meta)
+	assert.Equal(t, types.Milliseconds, meta.Precision)
+	parser, err = NewTimeParser("2006-01-02")
+	assert.NoError(t, err)
+	value, err = parser.Parse("2001-01-01")
+	assert.NoError(t, err)
+	assert.Equal(t, 978307200.0, value)
+	parser.Describe(meta)
+	assert.Equal(t, types.Seconds, meta.Precision)
+	_, err = NewTimeParser("2006|Nowhere/Special")
+	assert.Error(t, err)
+	_, err = NewTimeParser("2006||fortnight")
+	assert.Error(t, err)
+}
+
+func TestUnitParser(t *testing.T) {
+	parser, err := NewUnitParser("degF->degC")
+	assert.NoError(t, err)
+	value, err := parser.Parse("212")
+	assert.NoError(t, err)
+	assert.InDelta(t, 100.0, value, 1e-9)
+	meta := &types.Meta{}
+	parser.Describe(meta)
+	assert.Equal(t, "degC", meta.Unit)
+	parser, err = NewUnitParser("mi->km")
+	assert.NoError(t, err)
+	value, err = parser.Parse("10")
+	assert.NoError(t, err)
+	assert.InDelta(t, 16.09344, value, 1e-9)
+	parser, err = NewUnitParser("km")
+	assert.NoError(t, err)
+	value, err = parser.Parse("10")
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, value)
+	_, err = NewUnitParser("degF->km")
+	assert.Error(t, err)
+	_, err = NewUnitParser("furlong->km")
+	assert.Error(t, err)
+}
+
+func TestRegexParser(t *testing.T) {
+	parser, err := NewRegexParser(`temp=([0-9.]+)C`)
+	assert.NoError(t, err)
+	value, err := parser.Parse("temp=21.4C")
+	assert.NoError(t, err)
+	assert.Equal(t, 21.4, value)
+	_, err = parser.Parse("humidity=40%")
+	assert.Error(t, err)
+	parser, err = NewRegexParser(`(\w+): (?P<value>\d+)ms`)
+	assert.NoError(t, err)
+	value, err = parser.Parse("latency: 120ms")
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, value)
+	parser, err = NewRegexParser(`\d+`)
+	assert.NoError(t, err)
+	value, err = parser.Parse("about 42 items")
+	assert.NoError(t, err)
+	assert.Equal(t, 42.0, value)
+	parser, err = NewRegexParser(`(\w+)=([0-9.]+)|2`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, parser.Group)
+	value, err = parser.Parse("temp=21.4")
+	assert.NoError(t, err)
+	assert.Equal(t, 21.4, value)
+	// Alternatives are not mistaken for a group
+	parser, err = NewRegexParser(`x|2`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, parser.Group)
+	value, err = parser.Parse("2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	_, err = NewRegexParser(`(`)
+	assert.Error(t, err)
+	parsers, err := ParsersFromArgs([]string{
+		"ts,Regex,^at (.*)$",
+		"ts,Time,2006-01-02|UTC|ms",
+	})
+	assert.NoError(t, err)
+	value, err = parsers["ts"].Parse("at 2001-01-01")
+	assert.NoError(t, err)
+	assert.Equal(t, 978307200000.0, value)
+	meta := &types.Meta{}
+	parsers["ts"].(Describer).Describe(meta)
+	assert.Equal(t, types.Milliseconds, meta.Precision)
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"github.com/kevinschoon/fit/types"
+	"strconv"
+	"strings"
+)
+
+// Transformer is implemented by Parsers which
+// can pass modified text to the next Parser
+// of a Pipeline
+type Transformer interface {
+	Transform(string) (string, error)
+}
+
+// Pipeline chains several Parsers together. Each
+// Parser but the last must be a Transformer and
+// the text it returns is given to the next. The
+// last Parser returns the final value.
+//
+// Pipeline{TrimParser{}, regex, FloatParser{}}
+type Pipeline []Parser
+
+func (p Pipeline) Parse(v string) (float64, error) {
+	if len(p) == 0 {
+		return 0.0, fmt.Errorf("Empty pipeline")
+	}
+	for _, parser := range p[:len(p)-1] {
+		transformer, ok := parser.(Transformer)
+		if !ok {
+			return 0.0, fmt.Errorf("Parser %T cannot be chained", parser)
+		}
+		str, err := transformer.Transform(v)
+		if err != nil {
+			return 0.0, err
+		}
+		v = str
+	}
+	return p[len(p)-1].Parse(v)
+}
+
+func (p Pipeline) Describe(meta *types.Meta) {
+	for _, parser := range p {
+		if describer, ok := parser.(Describer); ok {
+			describer.Describe(meta)
+		}
+	}
+}
+
+// Chain appends next to parser returning a Pipeline.
+// An error is returned if parser cannot pass text
+// to another Parser.
+func Chain(parser, next Parser) (Pipeline, error) {
+	pipeline, ok := parser.(Pipeline)
+	if !ok {
+		pipeline = Pipeline{parser}
+	}
+	if len(pipeline) > 0 {
+		if _, ok := pipeline[len(pipeline)-1].(Transformer); !ok {
+			return nil, fmt.Errorf("Parser %T cannot be chained", pipeline[len(pipeline)-1])
+		}
+	}
+	return append(pipeline[:len(pipeline):len(pipeline)], next), nil
+}
+
+// TrimParser removes the characters in Cutset,
+// or whitespace if Cutset is empty, from both
+// ends of a value
+type TrimParser struct {
+	Cutset string
+}
+
+func (t TrimParser) Transform(v string) (string, error) {
+	if t.Cutset == "" {
+		return strings.TrimSpace(v), nil
+	}
+	return strings.Trim(v, t.Cutset), nil
+}
+
+func (t TrimParser) Parse(v string) (float64, error) {
+	str, _ := t.Transform(v)
+	return strconv.ParseFloat(str, 64)
+}
+
+// FloatParser parses a value as a float
+type FloatParser struct{}
+
+func (f FloatParser) Parse(v string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(v), 64)
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory returns a new Parser configured
+// with the options given in an argument
+type Factory func(opts string) (Parser, error)
+
+var (
+	registry     = make(map[string]Factory)
+	registryLock sync.RWMutex
+)
+
+// Register makes a Parser available to ParsersFromArgs
+// by name. Register panics if the name is already
+// registered or factory is nil.
+func Register(name string, factory Factory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if factory == nil {
+		panic("parser: Register factory is nil")
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("parser: Register called twice for %s", name))
+	}
+	registry[name] = factory
+}
+
+// unregister removes a parser registered with name
+func unregister(name string) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	delete(registry, name)
+}
+
+// Registered returns the sorted names of all
+// registered parsers
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New returns a Parser from the factory
+// registered with name
+func New(name, opts string) (Parser, error) {
+	registryLock.RLock()
+	factory, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Unknown parser: %s", name)
+	}
+	return factory(opts)
+}
+
+func init() {
+	Register("Time", func(opts string) (Parser, error) { return NewTimeParser(opts) })
+	Register("Bool", func(string) (Parser, error) { return BoolParser{}, nil })
+	Register("Percent", func(string) (Parser, error) { return PercentParser{}, nil })
+	Register("Currency", func(opts string) (Parser, error) { return NewCurrencyParser(opts) })
+	Register("Enum", func(opts string) (Parser, error) { return NewEnumParser(opts) })
+	Register("Unit", func(opts string) (Parser, error) { return NewUnitParser(opts) })
+	Register("Regex", func(opts string) (Parser, error) { return NewRegexParser(opts) })
+	Register("Trim", func(opts string) (Parser, error) { return TrimParser{Cutset: opts}, nil })
+	Register("Float", func(string) (Parser, error) { return FloatParser{}, nil })
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	Register("Upper", func(string) (Parser, error) {
+		return EnumParser{Values: map[string]float64{"A": 1, "B": 2}}, nil
+	})
+	defer unregister("Upper")
+	assert.Contains(t, Registered(), "Upper")
+	assert.Contains(t, Registered(), "Time")
+	parsers, err := ParsersFromArgs([]string{"grade,Upper"})
+	assert.NoError(t, err)
+	value, err := parsers["grade"].Parse("B")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	assert.Panics(t, func() {
+		Register("Upper", func(string) (Parser, error) { return FloatParser{}, nil })
+	})
+	assert.Panics(t, func() { Register("Nil", nil) })
+	_, err = New("Lower", "")
+	assert.EqualError(t, err, "Unknown parser: Lower")
+}
+
+func TestPipeline(t *testing.T) {
+	parsers, err := ParsersFromArgs([]string{
+		"latency,Trim",
+		"latency,Regex,latency: (\\d+)ms",
+		"latency,Float",
+	})
+	assert.NoError(t, err)
+	assert.IsType(t, Pipeline{}, parsers["latency"])
+	assert.Len(t, parsers["latency"], 3)
+	value, err := parsers["latency"].Parse("  latency: 120ms  ")
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, value)
+	_, err = parsers["latency"].Parse("latency: slow")
+	assert.Error(t, err)
+	_, err = ParsersFromArgs([]string{"x,Float", "x,Trim"})
+	assert.Error(t, err)
+	pipeline := Pipeline{TrimParser{Cutset: "*"}, CurrencyParser{}}
+	value, err = pipeline.Parse("**$1,000**")
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, value)
+	_, err = Pipeline{FloatParser{}, FloatParser{}}.Parse("1")
+	assert.True(t, strings.Contains(err.Error(), "cannot be chained"))
+}
@@ -0,0 +1,370 @@
+package regression
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat/distuv"
+	"github.com/kevinschoon/fit/types"
+	"math"
+	"sort"
+)
+
+// Curve is a nonlinear model of y as a function
+// of x with named parameters
+type Curve struct {
+	Name    string
+	Formula string   // Description of the model
+	Params  []string // Name of each parameter
+	Func    func(x float64, p []float64) float64
+	Guess   func(xs, ys []float64) []float64 // Initial parameters estimated from the data
+}
+
+// Curves contains each of the built-in models
+var Curves = map[string]Curve{
+	"exp": {
+		Name:    "exp",
+		Formula: "a * exp(-b * x) + c",
+		Params:  []string{"a", "b", "c"},
+		Func: func(x float64, p []float64) float64 {
+			return p[0]*math.Exp(-p[1]*x) + p[2]
+		},
+		Guess: guessExp,
+	},
+	"logistic": {
+		Name:    "logistic",
+		Formula: "L / (1 + exp(-k * (x - x0)))",
+		Params:  []string{"L", "k", "x0"},
+		Func: func(x float64, p []float64) float64 {
+			return p[0] / (1 + math.Exp(-p[1]*(x-p[2])))
+		},
+		Guess: guessLogistic,
+	},
+	"power": {
+		Name:    "power",
+		Formula: "a * x^b",
+		Params:  []string{"a", "b"},
+		Func: func(x float64, p []float64) float64 {
+			return p[0] * math.Pow(x, p[1])
+		},
+		Guess: guessPower,
+	},
+	"gaussian": {
+		Name:    "gaussian",
+		Formula: "a * exp(-(x - mu)^2 / (2 * sigma^2))",
+		Params:  []string{"a", "mu", "sigma"},
+		Func: func(x float64, p []float64) float64 {
+			return p[0] * math.Exp(-(x-p[1])*(x-p[1])/(2*p[2]*p[2]))
+		},
+		Guess: guessGaussian,
+	},
+}
+
+// GetCurve returns a built-in model by name
+func GetCurve(name string) (Curve, error) {
+	curve, ok := Curves[name]
+	if !ok {
+		return Curve{}, fmt.Errorf("Unknown curve: %s", name)
+	}
+	return curve, nil
+}
+
+// CurveFit is the result of fitting a Curve
+type CurveFit struct {
+	Curve        string
+	Formula      string
+	Params       []string
+	Values       []float64 // Estimated value of each parameter
+	StdErrors    []float64 // Standard error of each parameter
+	Lower        []float64 // Lower bound of the 95% confidence interval
+	Upper        []float64 // Upper bound of the 95% confidence interval
+	RSquared     float64
+	Residuals    []float64 // Residual of each row used in the fit
+	Observations int       // Number of rows used in the fit
+	Iterations   int
+}
+
+// Param returns the value of a parameter or NaN
+// if the parameter is not part of the curve
+func (fit CurveFit) Param(name string) float64 {
+	for i, param := range fit.Params {
+		if param == name {
+			return fit.Values[i]
+		}
+	}
+	return math.NaN()
+}
+
+// Predict returns the value of the fitted curve at x
+func (fit CurveFit) Predict(x float64) float64 {
+	curve, err := GetCurve(fit.Curve)
+	if err != nil {
+		return math.NaN()
+	}
+	return curve.Func(x, fit.Values)
+}
+
+const (
+	maxIterations = 200
+	tolerance     = 1e-10
+)
+
+// jacobian estimates the partial derivative of the
+// curve with respect to each parameter at each x
+// with central differences
+func jacobian(curve Curve, xs, p []float64) *mtx.Dense {
+	jac := mtx.NewDense(len(xs), len(p), nil)
+	step := make([]float64, len(p))
+	for j := range p {
+		h := 1e-6 * math.Max(math.Abs(p[j]), 1e-3)
+		copy(step, p)
+		step[j] = p[j] + h
+		for i, x := range xs {
+			jac.Set(i, j, curve.Func(x, step))
+		}
+		step[j] = p[j] - h
+		for i, x := range xs {
+			jac.Set(i, j, (jac.At(i, j)-curve.Func(x, step))/(2*h))
+		}
+	}
+	return jac
+}
+
+func sumSquares(curve Curve, xs, ys, p []float64) float64 {
+	ssr := 0.0
+	for i, x := range xs {
+		r := ys[i] - curve.Func(x, p)
+		ssr += r * r
+	}
+	if math.IsNaN(ssr) {
+		return math.Inf(1)
+	}
+	return ssr
+}
+
+// FitCurve fits the curve to the values with the
+// Levenberg-Marquardt algorithm starting from guess
+// or the curve's own estimate if guess is nil.
+func FitCurve(curve Curve, xs, ys, guess []float64) (*CurveFit, error) {
+	n, m := len(xs), len(curve.Params)
+	if len(ys) != n {
+		return nil, fmt.Errorf("Curve rows do not match: %d, %d", n, len(ys))
+	}
+	if n <= m {
+		return nil, fmt.Errorf("Curve fit needs more rows than parameters: %d rows, %d parameters", n, m)
+	}
+	p := guess
+	if p == nil {
+		p = curve.Guess(xs, ys)
+	}
+	if len(p) != m {
+		return nil, fmt.Errorf("Curve %s has %d parameters but %d were guessed", curve.Name, m, len(p))
+	}
+	p = append([]float64(nil), p...)
+	ssr := sumSquares(curve, xs, ys, p)
+	if math.IsInf(ssr, 1) {
+		return nil, fmt.Errorf("Curve %s cannot be evaluated at the initial parameters %v", curve.Name, p)
+	}
+	fit := &CurveFit{
+		Curve:        curve.Name,
+		Formula:      curve.Formula,
+		Params:       curve.Params,
+		Observations: n,
+	}
+	lambda := 1e-3
+	next := make([]float64, m)
+	for fit.Iterations = 0; fit.Iterations < maxIterations; fit.Iterations++ {
+		jac := jacobian(curve, xs, p)
+		residuals := mtx.NewDense(n, 1, nil)
+		for i, x := range xs {
+			residuals.Set(i, 0, ys[i]-curve.Func(x, p))
+		}
+		jtj := mtx.NewDense(m, m, nil)
+		jtj.Mul(jac.T(), jac)
+		jtr := mtx.NewDense(m, 1, nil)
+		jtr.Mul(jac.T(), residuals)
+		improved := false
+		for lambda < 1e12 {
+			// Damp the diagonal, larger lambda moves
+			// towards gradient descent
+			damped := mtx.DenseCopyOf(jtj)
+			for j := 0; j < m; j++ {
+				damped.Set(j, j, jtj.At(j, j)*(1+lambda)+1e-12)
+			}
+			delta := mtx.NewDense(m, 1, nil)
+			if err := delta.Solve(damped, jtr); err != nil {
+				lambda *= 10
+				continue
+			}
+			for j := range p {
+				next[j] = p[j] + delta.At(j, 0)
+			}
+			if value := sumSquares(curve, xs, ys, next); value < ssr {
+				converged := (ssr - value) <= tolerance*ssr
+				copy(p, next)
+				ssr = value
+				lambda /= 10
+				improved = !converged
+				break
+			}
+			lambda *= 10
+		}
+		if !improved {
+			break
+		}
+	}
+	fit.Values = p
+	fit.Residuals = make([]float64, n)
+	mean := 0.0
+	for i, x := range xs {
+		fit.Residuals[i] = ys[i] - curve.Func(x, p)
+		mean += ys[i] / float64(n)
+	}
+	sst := 0.0
+	for _, y := range ys {
+		sst += (y - mean) * (y - mean)
+	}
+	if sst > 0 {
+		fit.RSquared = 1 - ssr/sst
+	}
+	// Standard errors from the covariance s^2 (J'J)^-1
+	jac := jacobian(curve, xs, p)
+	jtj := mtx.NewDense(m, m, nil)
+	jtj.Mul(jac.T(), jac)
+	cov := mtx.NewDense(m, m, nil)
+	if err := cov.Inverse(jtj); err != nil {
+		return nil, fmt.Errorf("Curve %s is singular at %v, parameters may not be identifiable", curve.Name, p)
+	}
+	dof := float64(n - m)
+	t := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: dof}.Quantile(0.975)
+	fit.StdErrors = make([]float64, m)
+	fit.Lower = make([]float64, m)
+	fit.Upper = make([]float64, m)
+	for j := range p {
+		fit.StdErrors[j] = math.Sqrt(ssr / dof * cov.At(j, j))
+		fit.Lower[j] = p[j] - t*fit.StdErrors[j]
+		fit.Upper[j] = p[j] + t*fit.StdErrors[j]
+	}
+	return fit, nil
+}
+
+// FitCurveColumns fits the curve to the x and y columns
+// of the dataset skipping rows missing either value
+func FitCurveColumns(ds *types.Dataset, curve Curve, x, y string, guess []float64) (*CurveFit, error) {
+	xi, yi := ds.CPos(x), ds.CPos(y)
+	if xi < 0 {
+		return nil, fmt.Errorf("Curve column not found: %s", x)
+	}
+	if yi < 0 {
+		return nil, fmt.Errorf("Curve column not found: %s", y)
+	}
+	xs, ys := make([]float64, 0, ds.Len()), make([]float64, 0, ds.Len())
+	for i := 0; i < ds.Len(); i++ {
+		if xv, yv := ds.Mtx.At(i, xi), ds.Mtx.At(i, yi); !math.IsNaN(xv) && !math.IsNaN(yv) {
+			xs, ys = append(xs, xv), append(ys, yv)
+		}
+	}
+	return FitCurve(curve, xs, ys, guess)
+}
+
+// line fits y = a + b*x returning a and b
+func line(xs, ys []float64) (float64, float64) {
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i] / float64(len(xs))
+		my += ys[i] / float64(len(ys))
+	}
+	var sxy, sxx float64
+	for i := range xs {
+		sxy += (xs[i] - mx) * (ys[i] - my)
+		sxx += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if sxx == 0 {
+		return my, 0
+	}
+	return my - sxy/sxx*mx, sxy / sxx
+}
+
+func bounds(values []float64) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		min, max = math.Min(min, v), math.Max(max, v)
+	}
+	return min, max
+}
+
+// guessExp fits log(y - c) to a line with c just
+// below the smallest value
+func guessExp(xs, ys []float64) []float64 {
+	min, max := bounds(ys)
+	span := math.Max(max-min, 1e-9)
+	c := min - 0.01*span
+	logs := make([]float64, len(ys))
+	for i, y := range ys {
+		logs[i] = math.Log(y - c)
+	}
+	a, b := line(xs, logs)
+	return []float64{math.Exp(a), -b, c}
+}
+
+func guessLogistic(xs, ys []float64) []float64 {
+	min, max := bounds(ys)
+	L := max * 1.05
+	if max <= 0 {
+		L = max
+	}
+	// x0 is where y is closest to half of L
+	x0, closest := xs[0], math.Inf(1)
+	for i, y := range ys {
+		if d := math.Abs(y - L/2); d < closest {
+			x0, closest = xs[i], d
+		}
+	}
+	xmin, xmax := bounds(xs)
+	k := 4 / math.Max(xmax-xmin, 1e-9)
+	if _, slope := line(xs, ys); slope < 0 {
+		k = -k
+	}
+	if min == max {
+		k = 0
+	}
+	return []float64{L, k, x0}
+}
+
+func guessPower(xs, ys []float64) []float64 {
+	lx, ly := make([]float64, 0, len(xs)), make([]float64, 0, len(ys))
+	for i := range xs {
+		if xs[i] > 0 && ys[i] > 0 {
+			lx, ly = append(lx, math.Log(xs[i])), append(ly, math.Log(ys[i]))
+		}
+	}
+	if len(lx) < 2 {
+		return []float64{1, 1}
+	}
+	a, b := line(lx, ly)
+	return []float64{math.Exp(a), b}
+}
+
+// guessGaussian uses the peak and the width of
+// the values above half of the peak
+func guessGaussian(xs, ys []float64) []float64 {
+	peak := 0
+	for i, y := range ys {
+		if y > ys[peak] {
+			peak = i
+		}
+	}
+	above := make([]float64, 0, len(xs))
+	for i, y := range ys {
+		if y >= ys[peak]/2 {
+			above = append(above, xs[i])
+		}
+	}
+	sort.Float64s(above)
+	width := above[len(above)-1] - above[0]
+	if width == 0 {
+		xmin, xmax := bounds(xs)
+		width = (xmax - xmin) / 4
+	}
+	// The full width at half maximum is 2.355 sigma
+	return []float64{ys[peak], xs[peak], math.Max(width/2.355, 1e-9)}
+}
@@ -0,0 +1,47 @@
+package regression
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFitCurve(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for name, params := range map[string][]float64{
+		"exp":      {10, 0.5, 2},
+		"logistic": {100, 1.2, 5},
+		"power":    {3, 1.5},
+		"gaussian": {8, 4, 1.5},
+	} {
+		curve, err := GetCurve(name)
+		assert.NoError(t, err)
+		xs, ys := make([]float64, 0), make([]float64, 0)
+		for x := 0.1; x < 10; x += 0.1 {
+			xs = append(xs, x)
+			ys = append(ys, curve.Func(x, params)+random.NormFloat64()*0.01)
+		}
+		fit, err := FitCurve(curve, xs, ys, nil)
+		assert.NoError(t, err, name)
+		for i, param := range curve.Params {
+			assert.InDelta(t, params[i], fit.Param(param), 0.05*math.Abs(params[i]), name+" "+param)
+			assert.True(t, fit.Lower[i] < fit.Values[i] && fit.Values[i] < fit.Upper[i], name)
+			assert.True(t, fit.StdErrors[i] > 0, name)
+		}
+		assert.True(t, fit.RSquared > 0.99, name)
+		assert.InDelta(t, curve.Func(5, fit.Values), fit.Predict(5), 1e-12)
+		assert.Len(t, fit.Residuals, len(xs))
+	}
+	_, err := GetCurve("nope")
+	assert.Error(t, err)
+	curve, _ := GetCurve("power")
+	_, err = FitCurve(curve, []float64{1, 2}, []float64{1, 2}, nil)
+	assert.Error(t, err)
+	_, err = FitCurve(curve, []float64{1, 2, 3}, []float64{1, 2, 3}, []float64{1})
+	assert.Error(t, err)
+	fit, err := FitCurve(curve, []float64{1, 2, 3, 4}, []float64{2, 8, 18, 32}, []float64{1, 1})
+	assert.NoError(t, err)
+	assert.InDelta(t, 2, fit.Param("a"), 1e-6)
+	assert.InDelta(t, 2, fit.Param("b"), 1e-6)
+}
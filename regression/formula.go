@@ -0,0 +1,165 @@
+package regression
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/kevinschoon/fit/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Term is a column raised to a power
+type Term struct {
+	Column string
+	Power  int
+}
+
+func (t Term) String() string {
+	if t.Power == 1 {
+		return t.Column
+	}
+	return fmt.Sprintf("%s^%d", t.Column, t.Power)
+}
+
+// Formula describes a linear model of a response
+// column as the sum of terms. An intercept is
+// included unless the term 0 is given.
+//
+// y ~ x1 + x2
+// y ~ x + x^2
+// y ~ poly(x, 3) + 0
+type Formula struct {
+	Response  string
+	Terms     []Term
+	Intercept bool
+}
+
+func (f Formula) String() string {
+	terms := make([]string, 0, len(f.Terms)+1)
+	for _, term := range f.Terms {
+		terms = append(terms, term.String())
+	}
+	if !f.Intercept {
+		terms = append(terms, "0")
+	}
+	return fmt.Sprintf("%s ~ %s", f.Response, strings.Join(terms, " + "))
+}
+
+// ParseFormula parses a formula such as y ~ x1 + x2
+func ParseFormula(str string) (*Formula, error) {
+	split := strings.SplitN(str, "~", 2)
+	if len(split) != 2 || strings.TrimSpace(split[0]) == "" {
+		return nil, fmt.Errorf("Bad formula, use y ~ x1 + x2: %s", str)
+	}
+	formula := &Formula{
+		Response:  strings.TrimSpace(split[0]),
+		Intercept: true,
+	}
+	for _, arg := range strings.Split(split[1], "+") {
+		arg = strings.TrimSpace(arg)
+		switch {
+		case arg == "0":
+			formula.Intercept = false
+		case arg == "1":
+			formula.Intercept = true
+		case strings.HasPrefix(arg, "poly(") && strings.HasSuffix(arg, ")"):
+			args := strings.Split(arg[5:len(arg)-1], ",")
+			if len(args) != 2 {
+				return nil, fmt.Errorf("Bad formula term, use poly(x, degree): %s", arg)
+			}
+			degree, err := strconv.Atoi(strings.TrimSpace(args[1]))
+			if err != nil || degree < 1 {
+				return nil, fmt.Errorf("Bad polynomial degree: %s", arg)
+			}
+			for power := 1; power <= degree; power++ {
+				formula.Terms = append(formula.Terms, Term{Column: strings.TrimSpace(args[0]), Power: power})
+			}
+		default:
+			term := Term{Column: arg, Power: 1}
+			if pos := strings.LastIndex(arg, "^"); pos > 0 {
+				power, err := strconv.Atoi(strings.TrimSpace(arg[pos+1:]))
+				if err != nil || power < 1 {
+					return nil, fmt.Errorf("Bad formula term: %s", arg)
+				}
+				term = Term{Column: strings.TrimSpace(arg[:pos]), Power: power}
+			}
+			if term.Column == "" {
+				return nil, fmt.Errorf("Bad formula term: %s", str)
+			}
+			formula.Terms = append(formula.Terms, term)
+		}
+	}
+	if len(formula.Terms) == 0 && !formula.Intercept {
+		return nil, fmt.Errorf("Formula has no terms: %s", str)
+	}
+	return formula, nil
+}
+
+// Fit fits the formula to the columns of the dataset.
+// If weights is not empty it names the column containing
+// the weight of each row. Rows missing any value used
+// in the fit are skipped.
+func Fit(ds *types.Dataset, formula *Formula, weights string) (*Model, error) {
+	columns := []string{formula.Response}
+	for _, term := range formula.Terms {
+		columns = append(columns, term.Column)
+	}
+	if weights != "" {
+		columns = append(columns, weights)
+	}
+	positions := make(map[string]int)
+	for _, column := range columns {
+		pos := ds.CPos(column)
+		if pos < 0 {
+			return nil, fmt.Errorf("Regression column not found: %s", column)
+		}
+		positions[column] = pos
+	}
+	rows := make([]int, 0, ds.Len())
+	for i := 0; i < ds.Len(); i++ {
+		missing := false
+		for _, pos := range positions {
+			if math.IsNaN(ds.Mtx.At(i, pos)) {
+				missing = true
+			}
+		}
+		if !missing {
+			rows = append(rows, i)
+		}
+	}
+	offset := 0
+	terms := make([]string, 0, len(formula.Terms)+1)
+	if formula.Intercept {
+		offset = 1
+		terms = append(terms, Intercept)
+	}
+	for _, term := range formula.Terms {
+		terms = append(terms, term.String())
+	}
+	x := mtx.NewDense(len(rows), len(terms), nil)
+	y := make([]float64, len(rows))
+	var w []float64
+	if weights != "" {
+		w = make([]float64, len(rows))
+	}
+	for i, row := range rows {
+		if formula.Intercept {
+			x.Set(i, 0, 1)
+		}
+		for j, term := range formula.Terms {
+			x.Set(i, j+offset, math.Pow(ds.Mtx.At(row, positions[term.Column]), float64(term.Power)))
+		}
+		y[i] = ds.Mtx.At(row, positions[formula.Response])
+		if w != nil {
+			w[i] = ds.Mtx.At(row, positions[weights])
+		}
+	}
+	model, err := OLS(x, y, w)
+	if err != nil {
+		return nil, err
+	}
+	model.Formula = formula.String()
+	model.Terms = terms
+	return model, nil
+}
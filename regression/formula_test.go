@@ -0,0 +1,51 @@
+package regression
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/kevinschoon/fit/types"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestParseFormula(t *testing.T) {
+	formula, err := ParseFormula("y ~ x1 + x2^2 + poly(x3, 2)")
+	assert.NoError(t, err)
+	assert.Equal(t, "y", formula.Response)
+	assert.True(t, formula.Intercept)
+	assert.Equal(t, []Term{{"x1", 1}, {"x2", 2}, {"x3", 1}, {"x3", 2}}, formula.Terms)
+	assert.Equal(t, "y ~ x1 + x2^2 + x3 + x3^2", formula.String())
+	formula, err = ParseFormula("y~x+0")
+	assert.NoError(t, err)
+	assert.False(t, formula.Intercept)
+	assert.Equal(t, "y ~ x + 0", formula.String())
+	for _, str := range []string{"y", "~ x", "y ~ x^a", "y ~ poly(x)", "y ~ poly(x, 0)", "y ~ x + ", "y ~ 0"} {
+		_, err := ParseFormula(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestFit(t *testing.T) {
+	ds := &types.Dataset{
+		Columns: []string{"x", "y", "w"},
+		Mtx: mtx.NewDense(5, 3, []float64{
+			0, 1, 1,
+			1, 3, 1,
+			2, 5, 1,
+			3, math.NaN(), 1,
+			4, 9, 2,
+		}),
+	}
+	formula, err := ParseFormula("y ~ x")
+	assert.NoError(t, err)
+	model, err := Fit(ds, formula, "w")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, model.Observations)
+	assert.Equal(t, "y ~ x", model.Formula)
+	assert.InDelta(t, 1, model.Coefficient(Intercept), 1e-9)
+	assert.InDelta(t, 2, model.Coefficient("x"), 1e-9)
+	formula, err = ParseFormula("y ~ z")
+	assert.NoError(t, err)
+	_, err = Fit(ds, formula, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+// Package regression fits linear models to the
+// columns of a Dataset with least squares.
+package regression
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+)
+
+// Intercept is the name of the intercept term
+const Intercept = "(Intercept)"
+
+// Model is the result of a least squares fit
+type Model struct {
+	Formula      string    `json:",omitempty"`
+	Terms        []string  // Name of each coefficient
+	Coefficients []float64 // Estimated coefficient of each term
+	StdErrors    []float64 // Standard error of each coefficient
+	RSquared     float64
+	Residuals    []float64 // Residual of each row used in the fit
+	Observations int       // Number of rows used in the fit
+}
+
+// Coefficient returns the coefficient of a term
+// or NaN if the term is not in the model
+func (m Model) Coefficient(term string) float64 {
+	for i, name := range m.Terms {
+		if name == term {
+			return m.Coefficients[i]
+		}
+	}
+	return math.NaN()
+}
+
+// Predict returns the fitted value for a row
+// containing a value for each term
+func (m Model) Predict(row []float64) float64 {
+	value := 0.0
+	for i, coefficient := range m.Coefficients {
+		value += coefficient * row[i]
+	}
+	return value
+}
+
+// OLS fits y to the columns of x with ordinary
+// least squares. If weights is not nil each row
+// is weighted (weighted least squares). Include
+// a column of ones in x to fit an intercept.
+func OLS(x *mtx.Dense, y, weights []float64) (*Model, error) {
+	n, p := x.Dims()
+	if len(y) != n || (weights != nil && len(weights) != n) {
+		return nil, fmt.Errorf("Regression rows do not match: %d, %d", n, len(y))
+	}
+	if n <= p {
+		return nil, fmt.Errorf("Regression needs more rows than terms: %d rows, %d terms", n, p)
+	}
+	w := weights
+	if w == nil {
+		w = make([]float64, n)
+		for i := range w {
+			w[i] = 1
+		}
+	}
+	// Scaling each row by the square root of its
+	// weight reduces WLS to OLS
+	xw := mtx.NewDense(n, p, nil)
+	yw := mtx.NewDense(n, 1, nil)
+	for i := 0; i < n; i++ {
+		if w[i] < 0 || math.IsNaN(w[i]) {
+			return nil, fmt.Errorf("Bad regression weight: %f", w[i])
+		}
+		s := math.Sqrt(w[i])
+		for j := 0; j < p; j++ {
+			xw.Set(i, j, x.At(i, j)*s)
+		}
+		yw.Set(i, 0, y[i]*s)
+	}
+	beta := mtx.NewDense(p, 1, nil)
+	if err := beta.Solve(xw, yw); err != nil {
+		return nil, fmt.Errorf("Regression is singular, terms may be collinear: %s", err)
+	}
+	xtx := mtx.NewDense(p, p, nil)
+	xtx.Mul(xw.T(), xw)
+	cov := mtx.NewDense(p, p, nil)
+	if err := cov.Inverse(xtx); err != nil {
+		return nil, fmt.Errorf("Regression is singular, terms may be collinear: %s", err)
+	}
+	model := &Model{
+		Coefficients: mtx.Col(nil, 0, beta),
+		StdErrors:    make([]float64, p),
+		Residuals:    make([]float64, n),
+		Observations: n,
+	}
+	var ssr, sum, total float64
+	for i := 0; i < n; i++ {
+		model.Residuals[i] = y[i] - model.Predict(x.RawRowView(i))
+		ssr += w[i] * model.Residuals[i] * model.Residuals[i]
+		sum += w[i] * y[i]
+		total += w[i]
+	}
+	mean, sst := sum/total, 0.0
+	for i := 0; i < n; i++ {
+		sst += w[i] * (y[i] - mean) * (y[i] - mean)
+	}
+	if sst == 0 {
+		return nil, fmt.Errorf("Regression response has no variance")
+	}
+	model.RSquared = 1 - ssr/sst
+	sigma2 := ssr / float64(n-p)
+	for j := 0; j < p; j++ {
+		model.StdErrors[j] = math.Sqrt(sigma2 * cov.At(j, j))
+	}
+	return model, nil
+}
+
+// Polynomial fits y = b0 + b1*x + ... + bn*x^degree
+// with optional weights
+func Polynomial(x, y, weights []float64, degree int) (*Model, error) {
+	if degree < 1 {
+		return nil, fmt.Errorf("Bad polynomial degree: %d", degree)
+	}
+	design := mtx.NewDense(len(x), degree+1, nil)
+	for i, value := range x {
+		for j := 0; j <= degree; j++ {
+			design.Set(i, j, math.Pow(value, float64(j)))
+		}
+	}
+	model, err := OLS(design, y, weights)
+	if err != nil {
+		return nil, err
+	}
+	model.Terms = []string{Intercept}
+	for j := 1; j <= degree; j++ {
+		model.Terms = append(model.Terms, Term{Column: "x", Power: j}.String())
+	}
+	return model, nil
+}
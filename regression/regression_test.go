@@ -0,0 +1,71 @@
+package regression
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestOLS(t *testing.T) {
+	// y = 1 + 2*x1 - 3*x2 with small errors
+	x := mtx.NewDense(6, 3, []float64{
+		1, 0, 1,
+		1, 1, 0,
+		1, 2, 1,
+		1, 3, 3,
+		1, 4, 2,
+		1, 5, 4,
+	})
+	noise := []float64{0.1, -0.1, 0.05, -0.05, 0.02, -0.02}
+	y := make([]float64, 6)
+	for i := range y {
+		y[i] = 1 + 2*x.At(i, 1) - 3*x.At(i, 2) + noise[i]
+	}
+	model, err := OLS(x, y, nil)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1, model.Coefficients[0], 0.2)
+	assert.InDelta(t, 2, model.Coefficients[1], 0.1)
+	assert.InDelta(t, -3, model.Coefficients[2], 0.1)
+	assert.True(t, model.RSquared > 0.99)
+	assert.Len(t, model.Residuals, 6)
+	for i, residual := range model.Residuals {
+		assert.InDelta(t, y[i]-model.Predict(x.RawRowView(i)), residual, 1e-9)
+		assert.True(t, model.StdErrors[i%3] > 0)
+	}
+	// Collinear terms cannot be fit
+	collinear := mtx.NewDense(4, 3, []float64{1, 1, 2, 1, 2, 4, 1, 3, 6, 1, 4, 8})
+	_, err = OLS(collinear, []float64{1, 2, 3, 4}, nil)
+	assert.Error(t, err)
+	_, err = OLS(mtx.NewDense(2, 2, []float64{1, 1, 1, 2}), []float64{1, 2}, nil)
+	assert.Error(t, err)
+}
+
+func TestWeighted(t *testing.T) {
+	x := []float64{0, 1, 2, 3}
+	y := []float64{0, 1, 2, 10}
+	model, err := Polynomial(x, y, []float64{1, 1, 1, 0}, 1)
+	assert.NoError(t, err)
+	// The outlier has no weight
+	assert.InDelta(t, 0, model.Coefficients[0], 1e-9)
+	assert.InDelta(t, 1, model.Coefficients[1], 1e-9)
+	assert.InDelta(t, 7, model.Residuals[3], 1e-9)
+	_, err = Polynomial(x, y, []float64{1, 1, -1, 1}, 1)
+	assert.Error(t, err)
+}
+
+func TestPolynomial(t *testing.T) {
+	x := []float64{-2, -1, 0, 1, 2, 3}
+	y := make([]float64, len(x))
+	for i, v := range x {
+		y[i] = 3 - v + 0.5*v*v
+	}
+	model, err := Polynomial(x, y, nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{Intercept, "x", "x^2"}, model.Terms)
+	for i, expected := range []float64{3, -1, 0.5} {
+		assert.InDelta(t, expected, model.Coefficients[i], 1e-9)
+	}
+	assert.InDelta(t, 1, model.RSquared, 1e-9)
+	assert.True(t, math.IsNaN(model.Coefficient("x^3")))
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"github.com/gonum/plot/vg"
 	"github.com/kevinschoon/fit/chart"
+	"github.com/kevinschoon/fit/regression"
 	"github.com/kevinschoon/fit/types"
 	"image/color"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"text/template"
 )
 
@@ -41,7 +43,11 @@ func (handler Handler) response() *Response {
 }
 
 func (handler Handler) Chart(w http.ResponseWriter, r *http.Request) error {
-	ds, err := handler.db.Query(types.NewQueryQS(r.URL))
+	query, err := types.NewQueryQS(r.URL)
+	if err != nil {
+		return err
+	}
+	ds, err := handler.db.Query(query)
 	if err != nil {
 		return err
 	}
@@ -52,7 +58,48 @@ func (handler Handler) Chart(w http.ResponseWriter, r *http.Request) error {
 		Width:          18 * vg.Inch,
 		Height:         5 * vg.Inch,
 		Type:           r.URL.Query().Get("type"),
-		Columns:        types.NewQueryQS(r.URL).Columns(),
+		Columns:        ds.Columns,
+		Precision:      ds.ColumnMeta(0).Precision,
+	}
+	// Plot the X axis as time if the first column contains time values
+	cfg.PlotTime = cfg.Precision != ""
+	// Draw forecasts as a dashed continuation
+	if query.Forecast != nil {
+		cfg.Dashed = make(map[string]string)
+		for _, column := range query.Forecast.Columns() {
+			cfg.Dashed[column] = query.Forecast.Column
+		}
+	}
+	// Logarithmic axes as log=x, log=y or log=xy
+	cfg.LogX = strings.Contains(r.URL.Query().Get("log"), "x")
+	cfg.LogY = strings.Contains(r.URL.Query().Get("log"), "y")
+	// Color the points of scatter charts by cluster
+	if query.KMeans != nil {
+		cfg.Group = "cluster"
+	}
+	// Mark anomalies on the column they were detected in
+	for _, anomaly := range query.Anomalies {
+		cfg.Markers = append(cfg.Markers, chart.Marker{Column: anomaly.Column, Flag: anomaly.Output()})
+	}
+	// Overlay a curve fitted to a column against the first column
+	if arg := r.URL.Query().Get("curve"); arg != "" && len(ds.Columns) > 1 {
+		split := strings.Split(arg, ",")
+		column := ds.Columns[1]
+		if len(split) > 1 {
+			column = split[1]
+		}
+		curve, err := regression.GetCurve(split[0])
+		if err != nil {
+			return err
+		}
+		fit, err := regression.FitCurveColumns(ds, curve, ds.Columns[0], column, nil)
+		if err != nil {
+			return err
+		}
+		cfg.Overlays = append(cfg.Overlays, chart.Overlay{
+			Name: fmt.Sprintf("%s %s", column, curve.Name),
+			Func: fit.Predict,
+		})
 	}
 	if w, err := strconv.ParseInt(r.URL.Query().Get("width"), 0, 64); err == nil {
 		if w < 20 { // Prevent potentially horrible DOS
@@ -75,7 +122,10 @@ func (handler Handler) Chart(w http.ResponseWriter, r *http.Request) error {
 func (handler Handler) DatasetAPI(w http.ResponseWriter, r *http.Request) error {
 	switch r.Method {
 	case "GET":
-		query := types.NewQueryQS(r.URL)
+		query, err := types.NewQueryQS(r.URL)
+		if err != nil {
+			return err
+		}
 		if query.Len() > 0 { // If URL contains a query return the query result
 			ds, err := handler.db.Query(query)
 			if err != nil {
@@ -102,6 +152,21 @@ func (handler Handler) DatasetAPI(w http.ResponseWriter, r *http.Request) error
 		if err := handler.db.Write(ds); err != nil {
 			return err
 		}
+	case "PUT":
+		query := r.URL.Query()
+		name, rename := query.Get("name"), query.Get("rename")
+		if name == "" || rename == "" {
+			return fmt.Errorf("specify name and rename")
+		}
+		if column := query.Get("column"); column != "" {
+			if err := handler.db.RenameColumn(name, column, rename); err != nil {
+				return err
+			}
+		} else {
+			if err := handler.db.Rename(name, rename); err != nil {
+				return err
+			}
+		}
 	case "DELETE":
 		if name := r.URL.Query().Get("name"); name != "" {
 			if err := handler.db.Delete(name); err != nil {
@@ -114,6 +179,28 @@ func (handler Handler) DatasetAPI(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+// Regression fits the formula to the query result
+// and returns the model
+func (handler Handler) Regression(w http.ResponseWriter, r *http.Request) error {
+	formula, err := regression.ParseFormula(r.URL.Query().Get("formula"))
+	if err != nil {
+		return err
+	}
+	query, err := types.NewQueryQS(r.URL)
+	if err != nil {
+		return err
+	}
+	ds, err := handler.db.Query(query)
+	if err != nil {
+		return err
+	}
+	model, err := regression.Fit(ds, formula, r.URL.Query().Get("weights"))
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(model)
+}
+
 func (handler Handler) Home(w http.ResponseWriter, r *http.Request) error {
 	tmpl, err := template.ParseFiles(handler.templates...)
 	if err != nil {
@@ -151,7 +238,11 @@ func (handler Handler) Explore(w http.ResponseWriter, r *http.Request) error {
 		RawQuery: r.URL.Query().Encode(),
 	}
 	response.ChartURL = chartURL.String()
-	ds, err := handler.db.Query(types.NewQueryQS(r.URL))
+	query, err := types.NewQueryQS(r.URL)
+	if err != nil {
+		return err
+	}
+	ds, err := handler.db.Query(query)
 	if err != nil {
 		return err
 	}
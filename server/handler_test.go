@@ -3,7 +3,9 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	mtx "github.com/gonum/matrix/mat64"
 	"github.com/kevinschoon/fit/clients"
+	"github.com/kevinschoon/fit/regression"
 	"github.com/kevinschoon/fit/types"
 	"github.com/stretchr/testify/assert"
 	"io"
@@ -92,3 +94,30 @@ func TestDatasetAPI(t *testing.T) {
 		},
 	}))
 }
+
+func TestRegression(t *testing.T) {
+	db, cleanup := NewTestDB(t)
+	defer cleanup()
+	handler := Handler{db: db}
+	assert.NoError(t, db.Write(&types.Dataset{
+		Name:    "Line",
+		Columns: []string{"x", "y"},
+		Mtx:     mtx.NewDense(4, 2, []float64{0, 1, 1, 3, 2, 5, 3, 7}),
+	}))
+	writer, cleanup := NewMockWriter(t)
+	defer cleanup()
+	assert.NoError(t, handler.Regression(writer, &http.Request{
+		URL:    &url.URL{RawQuery: url.Values{"q": []string{"Line,*"}, "formula": []string{"y ~ x"}}.Encode()},
+		Method: "GET",
+	}))
+	raw, err := ioutil.ReadFile(writer.fp.Name())
+	assert.NoError(t, err)
+	model := &regression.Model{}
+	assert.NoError(t, json.Unmarshal(raw, model))
+	assert.Equal(t, []string{regression.Intercept, "x"}, model.Terms)
+	assert.InDelta(t, 2, model.Coefficient("x"), 1e-9)
+	assert.Error(t, handler.Regression(writer, &http.Request{
+		URL:    &url.URL{RawQuery: "q=Line,*&formula=y"},
+		Method: "GET",
+	}))
+}
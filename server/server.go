@@ -28,6 +28,10 @@ func HandleError(err error, w http.ResponseWriter, r *http.Request) {
 			switch err {
 			case types.ErrNotFound:
 				http.NotFound(w, r)
+			case types.ErrBadQuery:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case types.ErrExists:
+				http.Error(w, err.Error(), http.StatusConflict)
 			default:
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
@@ -68,6 +72,7 @@ func RunServer(db types.Client, pattern, path, version string, demo bool) {
 	router.Handle("/", ErrorHandler(handler.Home))
 	router.Handle("/explore", ErrorHandler(handler.Explore))
 	router.Handle("/chart", ErrorHandler(handler.Chart)).Methods("GET")
+	router.Handle("/1/regression", ErrorHandler(handler.Regression)).Methods("GET")
 	if demo {
 		router.Handle("/1/dataset", ErrorHandler(handler.DatasetAPI)).Methods("GET")
 	} else {
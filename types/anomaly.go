@@ -0,0 +1,167 @@
+package types
+
+import (
+	"fmt"
+	"github.com/gonum/stat"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Anomaly flags outliers in a column by adding a
+// column which is 1 for each outlier and 0 otherwise.
+// Missing values are never outliers.
+//
+// zscore: more than Threshold (3) standard deviations from the mean.
+// hampel: more than Threshold (3) scaled median absolute deviations
+// from the median of the Window (3) rows either side.
+// iqr: outside the quartiles by more than Threshold (1.5) times the
+// interquartile range.
+type Anomaly struct {
+	Method    string
+	Column    string
+	Threshold float64
+	Window    int // Rows either side of each value for hampel
+}
+
+func (an Anomaly) String() string {
+	return fmt.Sprintf("%s,%s,%s,%d", an.Method, an.Column, strconv.FormatFloat(an.Threshold, 'g', -1, 64), an.Window)
+}
+
+// Output returns the name of the resulting column
+func (an Anomaly) Output() string {
+	return an.Column + "_anomaly"
+}
+
+// present returns the values which are not missing
+func present(values []float64) []float64 {
+	result := make([]float64, 0, len(values))
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return math.NaN()
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// mad returns the median absolute deviation scaled
+// to estimate the standard deviation of normal data
+func mad(values []float64, m float64) float64 {
+	deviations := make([]float64, len(values))
+	for i, value := range values {
+		deviations[i] = math.Abs(value - m)
+	}
+	return 1.4826 * median(deviations)
+}
+
+// Flags returns 1 for each outlier and 0 otherwise
+func (an Anomaly) Flags(values []float64) ([]float64, error) {
+	flags := make([]float64, len(values))
+	valid := present(values)
+	if len(valid) == 0 {
+		return flags, nil
+	}
+	var outlier func(i int, value float64) bool
+	switch an.Method {
+	case "zscore":
+		mean, std := stat.MeanStdDev(valid, nil)
+		outlier = func(i int, value float64) bool {
+			return std > 0 && math.Abs(value-mean)/std > an.Threshold
+		}
+	case "hampel":
+		if an.Window < 1 {
+			return nil, fmt.Errorf("Bad anomaly window: %d", an.Window)
+		}
+		outlier = func(i int, value float64) bool {
+			start, end := i-an.Window, i+an.Window+1
+			if start < 0 {
+				start = 0
+			}
+			if end > len(values) {
+				end = len(values)
+			}
+			window := present(values[start:end])
+			m := median(window)
+			return math.Abs(value-m) > an.Threshold*mad(window, m)
+		}
+	case "iqr":
+		sorted := append([]float64(nil), valid...)
+		sort.Float64s(sorted)
+		q1 := stat.Quantile(0.25, stat.Empirical, sorted, nil)
+		q3 := stat.Quantile(0.75, stat.Empirical, sorted, nil)
+		iqr := q3 - q1
+		outlier = func(i int, value float64) bool {
+			return value < q1-an.Threshold*iqr || value > q3+an.Threshold*iqr
+		}
+	default:
+		return nil, fmt.Errorf("Unknown anomaly method: %s", an.Method)
+	}
+	for i, value := range values {
+		if !math.IsNaN(value) && outlier(i, value) {
+			flags[i] = 1
+		}
+	}
+	return flags, nil
+}
+
+// Apply adds the anomaly column to the dataset
+func (an Anomaly) Apply(ds *Dataset) error {
+	column := ds.CPos(an.Column)
+	if column < 0 {
+		return fmt.Errorf("Anomaly column not found: %s", an.Column)
+	}
+	values := make([]float64, ds.Len())
+	for i := range values {
+		values[i] = ds.Mtx.At(i, column)
+	}
+	flags, err := an.Flags(values)
+	if err != nil {
+		return err
+	}
+	ds.AddColumn(an.Output(), flags, Meta{})
+	return nil
+}
+
+// NewAnomaly returns an Anomaly from a string parameter
+// with the default threshold and window of the method
+//
+// zscore,value
+// hampel,value,3,5
+// ^------^-----^-^---Method,Column,Threshold,Window
+func NewAnomaly(arg string) *Anomaly {
+	split := strings.Split(arg, ",")
+	anomaly := &Anomaly{
+		Method:    strings.ToLower(split[0]),
+		Threshold: 3,
+		Window:    3,
+	}
+	if anomaly.Method == "iqr" {
+		anomaly.Threshold = 1.5
+	}
+	if len(split) >= 2 {
+		anomaly.Column = split[1]
+	}
+	if len(split) >= 3 && split[2] != "" {
+		threshold, _ := strconv.ParseFloat(split[2], 64)
+		anomaly.Threshold = threshold
+	}
+	if len(split) >= 4 {
+		window, _ := strconv.ParseInt(split[3], 0, 64)
+		anomaly.Window = int(window)
+	}
+	return anomaly
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestAnomaly(t *testing.T) {
+	nan := math.NaN()
+	values := []float64{10, 11, 9, 10, nan, 50, 10, 11, 9, 10}
+	for arg, expected := range map[string][]float64{
+		"zscore,v,2":   {0, 0, 0, 0, 0, 1, 0, 0, 0, 0},
+		"zscore,v":     {0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		"hampel,v":     {0, 0, 0, 0, 0, 1, 0, 0, 0, 0},
+		"hampel,v,3,1": {0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		"iqr,v":        {0, 0, 0, 0, 0, 1, 0, 0, 0, 0},
+		"IQR,v,100":    {0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	} {
+		result, err := NewAnomaly(arg).Flags(values)
+		assert.NoError(t, err, arg)
+		assert.Equal(t, expected, result, arg)
+	}
+	_, err := NewAnomaly("nope,v").Flags(values)
+	assert.Error(t, err)
+	_, err = NewAnomaly("hampel,v,3,0").Flags(values)
+	assert.Error(t, err)
+}
+
+func TestQueryAnomaly(t *testing.T) {
+	query := NewQuery([]string{"D0,value"}, "", "")
+	query.Anomalies = append(query.Anomalies, NewAnomaly("iqr,value"))
+	ds := &Dataset{
+		Columns: []string{"value"},
+		Mtx:     mtx.NewDense(5, 1, []float64{1, 2, 3, 2, 100}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"value", "value_anomaly"}, ds.Columns)
+	assert.Equal(t, []float64{0, 0, 0, 0, 1}, mtx.Col(nil, 1, ds.Mtx))
+	query.Anomalies = []*Anomaly{NewAnomaly("zscore,nope")}
+	assert.Error(t, query.Apply(ds))
+}
+
+func TestQueryGroupedAnomaly(t *testing.T) {
+	query := NewQuery([]string{"D0,time,value"}, "avg", "Duration,0,10s")
+	query.Anomalies = []*Anomaly{NewAnomaly("iqr,value")}
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{Precision: Seconds}, {}},
+		Mtx:     mtx.NewDense(60, 2, nil),
+	}
+	for i := 0; i < 60; i++ {
+		ds.Mtx.SetRow(i, []float64{float64(i), 1})
+	}
+	ds.Mtx.Set(35, 1, 100)
+	assert.NoError(t, query.Apply(ds))
+	// Flags of the grouped values are never averaged
+	values, flags := mtx.Col(nil, 1, ds.Mtx), mtx.Col(nil, 2, ds.Mtx)
+	flagged := 0
+	for i, flag := range flags {
+		assert.True(t, flag == 0 || flag == 1)
+		if flag == 1 {
+			flagged++
+			assert.True(t, values[i] > 1)
+		}
+	}
+	assert.Equal(t, 1, flagged)
+}
@@ -0,0 +1,136 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Autocorrelation is the correlation of a column
+// with itself at each lag from 0 to Lags rows.
+//
+// acf: autocorrelation
+// pacf: partial autocorrelation, the correlation
+// remaining after removing that of shorter lags
+type Autocorrelation struct {
+	Method string
+	Column string
+	Lags   int
+}
+
+func (ac Autocorrelation) String() string {
+	return fmt.Sprintf("%s,%s,%d", ac.Method, ac.Column, ac.Lags)
+}
+
+// acf returns the autocorrelation of values for
+// each lag ignoring pairs with missing values
+func acf(values []float64, lags int) []float64 {
+	m := mean(present(values))
+	var variance float64
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			variance += (value - m) * (value - m)
+		}
+	}
+	result := make([]float64, lags+1)
+	for k := range result {
+		var sum float64
+		for t := 0; t+k < len(values); t++ {
+			if a, b := values[t], values[t+k]; !math.IsNaN(a) && !math.IsNaN(b) {
+				sum += (a - m) * (b - m)
+			}
+		}
+		result[k] = sum / variance
+	}
+	return result
+}
+
+// pacf returns the partial autocorrelation for each
+// lag from the autocorrelation with Durbin-Levinson
+func pacf(r []float64) []float64 {
+	result := make([]float64, len(r))
+	result[0] = 1
+	previous := []float64{}
+	for k := 1; k < len(r); k++ {
+		numerator, denominator := r[k], 1.0
+		for j := 1; j < k; j++ {
+			numerator -= previous[j-1] * r[k-j]
+			denominator -= previous[j-1] * r[j]
+		}
+		phi := numerator / denominator
+		current := make([]float64, k)
+		for j := 1; j < k; j++ {
+			current[j-1] = previous[j-1] - phi*previous[k-j-1]
+		}
+		current[k-1] = phi
+		result[k] = phi
+		previous = current
+	}
+	return result
+}
+
+// Values returns the autocorrelation at each lag
+func (ac Autocorrelation) Values(values []float64) ([]float64, error) {
+	n := len(present(values))
+	if n < 2 {
+		return nil, fmt.Errorf("Autocorrelation requires at least two values")
+	}
+	if ac.Lags < 1 || ac.Lags >= n {
+		return nil, fmt.Errorf("Bad autocorrelation lags: %d", ac.Lags)
+	}
+	r := acf(values, ac.Lags)
+	switch ac.Method {
+	case "acf":
+		return r, nil
+	case "pacf":
+		return pacf(r), nil
+	}
+	return nil, fmt.Errorf("Unknown autocorrelation method: %s", ac.Method)
+}
+
+// Apply replaces the values of the dataset with the
+// lag, autocorrelation and the 95% confidence bounds
+// of zero autocorrelation
+func (ac Autocorrelation) Apply(ds *Dataset) error {
+	column := ds.CPos(ac.Column)
+	if column < 0 {
+		return fmt.Errorf("Autocorrelation column not found: %s", ac.Column)
+	}
+	values := mtx.Col(nil, column, ds.Mtx)
+	result, err := ac.Values(values)
+	if err != nil {
+		return err
+	}
+	bound := 1.96 / math.Sqrt(float64(len(present(values))))
+	mx := mtx.NewDense(len(result), 4, nil)
+	for i, value := range result {
+		mx.SetRow(i, []float64{float64(i), value, -bound, bound})
+	}
+	ds.Columns = []string{"lag", ac.Method, "lower", "upper"}
+	ds.Mtx = mx
+	ds.Meta = nil
+	return nil
+}
+
+// NewAutocorrelation returns an Autocorrelation
+// from a string parameter, Lags defaults to 20
+//
+// acf,temp,24
+// ^---^----^---Method,Column,Lags
+func NewAutocorrelation(arg string) *Autocorrelation {
+	split := strings.Split(arg, ",")
+	autocorrelation := &Autocorrelation{
+		Method: strings.ToLower(split[0]),
+		Lags:   20,
+	}
+	if len(split) >= 2 {
+		autocorrelation.Column = split[1]
+	}
+	if len(split) >= 3 {
+		lags, _ := strconv.ParseInt(split[2], 0, 64)
+		autocorrelation.Lags = int(lags)
+	}
+	return autocorrelation
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAutocorrelation(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	result, err := NewAutocorrelation("acf,v,2").Values(values)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{1, 0.4, -0.1}, result, 1e-9)
+	result, err = NewAutocorrelation("pacf,v,2").Values(values)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{1, 0.4, -0.3095238095}, result, 1e-9)
+	for _, arg := range []string{"nope,v,2", "acf,v,5", "acf,v,0"} {
+		_, err = NewAutocorrelation(arg).Values(values)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestQueryAutocorrelation(t *testing.T) {
+	query := NewQuery([]string{"D0,value"}, "", "")
+	query.Autocorrelation = NewAutocorrelation("acf,value,2")
+	ds := &Dataset{
+		Columns: []string{"value"},
+		Mtx:     mtx.NewDense(4, 1, []float64{1, -1, 1, -1}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"lag", "acf", "lower", "upper"}, ds.Columns)
+	assert.Equal(t, []float64{0, 1, -0.98, 0.98}, mtx.Row(nil, 0, ds.Mtx))
+	assert.Equal(t, []float64{1, -0.75, -0.98, 0.98}, mtx.Row(nil, 1, ds.Mtx))
+}
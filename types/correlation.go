@@ -0,0 +1,129 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"math"
+	"sort"
+)
+
+// Correlation replaces a dataset with the matrix of
+// correlations (or covariances) between each pair
+// of its columns. The result is preceded by a column
+// named column holding the position in Columns of
+// the column each row corresponds to, so row i
+// holds the values of column i+1. Rows missing
+// either value of a pair are ignored.
+//
+// pearson   linear correlation
+// spearman  correlation of the ranks
+// kendall   rank correlation tau-b
+// cov       sample covariance
+type Correlation struct {
+	Method string
+}
+
+func (corr Correlation) String() string {
+	return corr.Method
+}
+
+// pair returns the values of the columns i and j
+// from rows where neither is missing
+func pair(mx *mtx.Dense, i, j int) ([]float64, []float64) {
+	r, _ := mx.Dims()
+	xs, ys := make([]float64, 0, r), make([]float64, 0, r)
+	for k := 0; k < r; k++ {
+		x, y := mx.At(k, i), mx.At(k, j)
+		if !math.IsNaN(x) && !math.IsNaN(y) {
+			xs, ys = append(xs, x), append(ys, y)
+		}
+	}
+	return xs, ys
+}
+
+// Rank returns the rank of each value starting
+// at 1, tied values receive their average rank
+func Rank(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return values[order[a]] < values[order[b]] })
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && values[order[j+1]] == values[order[i]] {
+			j++
+		}
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[order[k]] = rank
+		}
+		i = j + 1
+	}
+	return ranks
+}
+
+// Kendall returns the tau-b rank correlation
+func Kendall(xs, ys []float64) float64 {
+	var concordant, discordant, tiesX, tiesY float64
+	for i := 0; i < len(xs); i++ {
+		for j := i + 1; j < len(xs); j++ {
+			dx, dy := xs[i]-xs[j], ys[i]-ys[j]
+			switch {
+			case dx == 0 && dy == 0:
+			case dx == 0:
+				tiesX++
+			case dy == 0:
+				tiesY++
+			case (dx > 0) == (dy > 0):
+				concordant++
+			default:
+				discordant++
+			}
+		}
+	}
+	return (concordant - discordant) / math.Sqrt((concordant+discordant+tiesX)*(concordant+discordant+tiesY))
+}
+
+// Between returns the correlation of two columns
+func (corr Correlation) Between(xs, ys []float64) (float64, error) {
+	if len(xs) < 2 {
+		return math.NaN(), nil
+	}
+	switch corr.Method {
+	case "", "pearson":
+		return stat.Correlation(xs, ys, nil), nil
+	case "spearman":
+		return stat.Correlation(Rank(xs), Rank(ys), nil), nil
+	case "kendall":
+		return Kendall(xs, ys), nil
+	case "cov":
+		return stat.Covariance(xs, ys, nil), nil
+	}
+	return math.NaN(), fmt.Errorf("Unknown correlation method: %s", corr.Method)
+}
+
+// Apply replaces the values of the dataset with
+// the correlation matrix of its columns
+func (corr Correlation) Apply(ds *Dataset) error {
+	_, c := ds.Mtx.Dims()
+	mx := mtx.NewDense(c, c+1, nil)
+	for i := 0; i < c; i++ {
+		mx.Set(i, 0, float64(i+1))
+		for j := i; j < c; j++ {
+			value, err := corr.Between(pair(ds.Mtx, i, j))
+			if err != nil {
+				return err
+			}
+			mx.Set(i, j+1, value)
+			mx.Set(j, i+1, value)
+		}
+	}
+	ds.Columns = append([]string{"column"}, ds.Columns...)
+	ds.Mtx = mx
+	// Correlations have no unit or precision
+	ds.Meta = nil
+	return nil
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	assert.Equal(t, []float64{2, 4, 2, 2, 5}, Rank([]float64{1, 3, 1, 1, 8}))
+	assert.Equal(t, []float64{}, Rank([]float64{}))
+}
+
+func TestCorrelation(t *testing.T) {
+	ds := &Dataset{
+		Columns: []string{"x", "y", "z"},
+		Meta:    []Meta{{Unit: "km"}},
+		Mtx: mtx.NewDense(5, 3, []float64{
+			1, 1, 5,
+			2, 4, 4,
+			3, 9, 3,
+			4, 16, math.NaN(),
+			5, 25, 1,
+		}),
+	}
+	for method, expected := range map[string][]float64{
+		// x,y  x,z  y,z
+		"pearson":  {0.9811, -1, -0.9815},
+		"spearman": {1, -1, -1},
+		"kendall":  {1, -1, -1},
+		"cov":      {15, -2.9167, -17.9167},
+	} {
+		corr := &Correlation{Method: method}
+		other := &Dataset{Columns: ds.Columns, Meta: ds.Meta, Mtx: mtx.DenseCopyOf(ds.Mtx)}
+		assert.NoError(t, corr.Apply(other))
+		r, c := other.Mtx.Dims()
+		assert.Equal(t, 3, r)
+		assert.Equal(t, 4, c)
+		assert.Nil(t, other.Meta)
+		assert.Equal(t, []string{"column", "x", "y", "z"}, other.Columns)
+		assert.Equal(t, []float64{1, 2, 3}, mtx.Col(nil, 0, other.Mtx))
+		assert.InDelta(t, expected[0], other.Mtx.At(0, 2), 1e-4, method)
+		assert.InDelta(t, expected[1], other.Mtx.At(0, 3), 1e-4, method)
+		assert.InDelta(t, expected[2], other.Mtx.At(2, 2), 1e-4, method)
+	}
+	assert.Equal(t, 1.0, Kendall([]float64{1, 2, 3}, []float64{1, 2, 3}))
+	assert.InDelta(t, 2/math.Sqrt(6), Kendall([]float64{1, 1, 2}, []float64{1, 2, 3}), 1e-9)
+	assert.Error(t, Correlation{Method: "nope"}.Apply(ds))
+}
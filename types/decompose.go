@@ -0,0 +1,254 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Decomposition splits a column with seasonality of
+// Period rows into trend, seasonal and residual columns
+// where the column is the sum of the three.
+//
+// classical: the trend is a centered moving average of
+// one period and the seasonal part is the average of the
+// detrended values at each position within the period.
+// stl: seasonal and trend decomposition using loess,
+// without the robustness iterations.
+type Decomposition struct {
+	Method string
+	Column string
+	Period int
+}
+
+func (dc Decomposition) String() string {
+	return fmt.Sprintf("%s,%s,%d", dc.Method, dc.Column, dc.Period)
+}
+
+// Columns returns the names of the trend,
+// seasonal and residual columns
+func (dc Decomposition) Columns() []string {
+	return []string{
+		dc.Column + "_trend",
+		dc.Column + "_seasonal",
+		dc.Column + "_residual",
+	}
+}
+
+// Components returns the trend, seasonal and residual
+// parts of values, the trend of a classical decomposition
+// is NaN for the first and last half period.
+func (dc Decomposition) Components(values []float64) (trend, seasonal, residual []float64, err error) {
+	if dc.Period < 2 {
+		return nil, nil, nil, fmt.Errorf("Bad decomposition period: %d", dc.Period)
+	}
+	if len(present(values)) < 2*dc.Period {
+		return nil, nil, nil, fmt.Errorf("Decomposition requires at least two periods of values")
+	}
+	switch dc.Method {
+	case "classical":
+		trend, seasonal = classical(values, dc.Period)
+	case "stl":
+		trend, seasonal = stl(values, dc.Period)
+	default:
+		return nil, nil, nil, fmt.Errorf("Unknown decomposition method: %s", dc.Method)
+	}
+	residual = make([]float64, len(values))
+	for i, value := range values {
+		residual[i] = value - trend[i] - seasonal[i]
+	}
+	return trend, seasonal, residual, nil
+}
+
+// Apply adds the trend, seasonal and
+// residual columns to the dataset
+func (dc Decomposition) Apply(ds *Dataset) error {
+	column := ds.CPos(dc.Column)
+	if column < 0 {
+		return fmt.Errorf("Decomposition column not found: %s", dc.Column)
+	}
+	trend, seasonal, residual, err := dc.Components(mtx.Col(nil, column, ds.Mtx))
+	if err != nil {
+		return err
+	}
+	meta := ds.ColumnMeta(column)
+	for i, values := range [][]float64{trend, seasonal, residual} {
+		ds.AddColumn(dc.Columns()[i], values, meta)
+	}
+	return nil
+}
+
+// movingAverage returns the centered moving average of
+// period values, averaging two windows when the period
+// is even. Values without a complete window are NaN.
+func movingAverage(values []float64, period int) []float64 {
+	result := nans(len(values))
+	half := period / 2
+	for i := half; i < len(values)-half; i++ {
+		var sum float64
+		for j := i - half; j <= i+half; j++ {
+			weight := 1.0
+			if period%2 == 0 && (j == i-half || j == i+half) {
+				weight = 0.5
+			}
+			sum += weight * values[j]
+		}
+		result[i] = sum / float64(period)
+	}
+	return result
+}
+
+// seasonalMeans returns the average of the values
+// at each position within the period centered on 0
+func seasonalMeans(values []float64, period int) []float64 {
+	means := make([]float64, period)
+	for p := range means {
+		means[p] = mean(present(subseries(values, p, period)))
+	}
+	centre := mean(means)
+	for p := range means {
+		means[p] -= centre
+	}
+	return means
+}
+
+// subseries returns every period value from position p
+func subseries(values []float64, p, period int) []float64 {
+	result := make([]float64, 0, len(values)/period+1)
+	for i := p; i < len(values); i += period {
+		result = append(result, values[i])
+	}
+	return result
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+func classical(values []float64, period int) (trend, seasonal []float64) {
+	trend = movingAverage(values, period)
+	detrended := make([]float64, len(values))
+	for i, value := range values {
+		detrended[i] = value - trend[i]
+	}
+	means := seasonalMeans(detrended, period)
+	seasonal = make([]float64, len(values))
+	for i := range seasonal {
+		seasonal[i] = means[i%period]
+	}
+	return trend, seasonal
+}
+
+// odd returns the smallest odd integer of at least value
+func odd(value float64) int {
+	n := int(math.Ceil(value))
+	if n%2 == 0 {
+		n++
+	}
+	return n
+}
+
+func stl(values []float64, period int) (trend, seasonal []float64) {
+	var (
+		n        = len(values)
+		sSpan    = 7
+		lSpan    = odd(float64(period))
+		tSpan    = odd(1.5 * float64(period) / (1 - 1.5/float64(sSpan)))
+		cycle    = make([]float64, n)
+		adjusted = make([]float64, n)
+	)
+	trend = make([]float64, n)
+	seasonal = make([]float64, n)
+	for iteration := 0; iteration < 2; iteration++ {
+		// Smooth each cycle subseries of the detrended values
+		for p := 0; p < period && p < n; p++ {
+			sub := subseries(values, p, period)
+			for i := range sub {
+				sub[i] -= trend[p+i*period]
+			}
+			for i, value := range loess(sub, sSpan) {
+				cycle[p+i*period] = value
+			}
+		}
+		// Remove any low frequency from the cycles
+		low := loess(movingAverage(movingAverage(cycle, period), 3), lSpan)
+		for i := range seasonal {
+			seasonal[i] = cycle[i] - low[i]
+			adjusted[i] = values[i] - seasonal[i]
+		}
+		trend = loess(adjusted, tSpan)
+	}
+	return trend, seasonal
+}
+
+// loess returns the locally weighted linear regression at
+// each position of values using the span nearest values
+// which are present with tricube weights
+func loess(values []float64, span int) []float64 {
+	xs := make([]int, 0, len(values))
+	for i, value := range values {
+		if !math.IsNaN(value) {
+			xs = append(xs, i)
+		}
+	}
+	result := nans(len(values))
+	q := span
+	if q > len(xs) {
+		q = len(xs)
+	}
+	if q == 0 {
+		return result
+	}
+	lo := 0
+	for i := range values {
+		// Slide the window of q points towards i
+		for lo+q < len(xs) && i-xs[lo] > xs[lo+q]-i {
+			lo++
+		}
+		h := math.Max(float64(i-xs[lo]), float64(xs[lo+q-1]-i)) + 1
+		var sw, sx, sy, sxx, sxy float64
+		for _, x := range xs[lo : lo+q] {
+			d := math.Abs(float64(x-i)) / h
+			w := math.Pow(1-d*d*d, 3)
+			fx, y := float64(x-i), values[x]
+			sw += w
+			sx += w * fx
+			sy += w * y
+			sxx += w * fx * fx
+			sxy += w * fx * y
+		}
+		if denominator := sw*sxx - sx*sx; math.Abs(denominator) > 1e-12 {
+			// Intercept of the local line at x = i
+			result[i] = (sy*sxx - sx*sxy) / denominator
+		} else {
+			result[i] = sy / sw
+		}
+	}
+	return result
+}
+
+// NewDecomposition returns a Decomposition from a
+// string parameter
+//
+// classical,temp,12
+// ^---------^----^---Method,Column,Period
+func NewDecomposition(arg string) *Decomposition {
+	split := strings.Split(arg, ",")
+	decomposition := &Decomposition{
+		Method: strings.ToLower(split[0]),
+	}
+	if len(split) >= 2 {
+		decomposition.Column = split[1]
+	}
+	if len(split) >= 3 {
+		period, _ := strconv.ParseInt(split[2], 0, 64)
+		decomposition.Period = int(period)
+	}
+	return decomposition
+}
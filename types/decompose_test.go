@@ -0,0 +1,67 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"net/url"
+	"testing"
+)
+
+func seasonalSeries(n int) []float64 {
+	pattern := []float64{1, -1, 2, -2}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = 0.5*float64(i) + pattern[i%4]
+	}
+	return values
+}
+
+func TestDecomposition(t *testing.T) {
+	values := seasonalSeries(16)
+	trend, seasonal, residual, err := NewDecomposition("classical,v,4").Components(values)
+	assert.NoError(t, err)
+	for i := range values {
+		assert.InDelta(t, []float64{1, -1, 2, -2}[i%4], seasonal[i], 1e-9)
+		if i < 2 || i >= 14 {
+			assert.True(t, math.IsNaN(trend[i]))
+			assert.True(t, math.IsNaN(residual[i]))
+			continue
+		}
+		assert.InDelta(t, 0.5*float64(i), trend[i], 1e-9)
+		assert.InDelta(t, 0, residual[i], 1e-9)
+	}
+	values = seasonalSeries(40)
+	trend, seasonal, residual, err = NewDecomposition("STL,v,4").Components(values)
+	assert.NoError(t, err)
+	for i := 4; i < 36; i++ {
+		assert.InDelta(t, 0.5*float64(i), trend[i], 0.1)
+		assert.InDelta(t, []float64{1, -1, 2, -2}[i%4], seasonal[i], 0.1)
+		assert.InDelta(t, values[i], trend[i]+seasonal[i]+residual[i], 1e-9)
+	}
+	for _, arg := range []string{"nope,v,4", "classical,v,1", "stl,v,24"} {
+		_, _, _, err = NewDecomposition(arg).Components(values)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestQueryDecomposition(t *testing.T) {
+	u, err := url.Parse("http://localhost/?q=D0,time,value&decompose=classical,value,4")
+	assert.NoError(t, err)
+	query, err := NewQueryQS(u)
+	assert.NoError(t, err)
+	assert.Equal(t, "classical,value,4", query.Decomposition.String())
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{}, {Unit: "km"}},
+		Mtx:     mtx.NewDense(16, 2, nil),
+	}
+	for i, value := range seasonalSeries(16) {
+		ds.Mtx.SetRow(i, []float64{float64(i), value})
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"time", "value", "value_trend", "value_seasonal", "value_residual"}, ds.Columns)
+	assert.Equal(t, "km", ds.ColumnMeta(3).Unit)
+	query.Decomposition.Column = "nope"
+	assert.Error(t, query.Apply(ds))
+}
@@ -0,0 +1,507 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is an arithmetic expression evaluated against
+// each row of a Dataset. Identifiers refer to column
+// names and may be quoted with backticks if they
+// contain other characters. Comparison and logical
+// operators return 1 or 0.
+//
+// Operators (lowest to highest precedence):
+// || && == != < <= > >= + - * / % unary(- !) ^
+//
+// Functions:
+// abs ceil floor round sqrt exp log log2 log10 sin cos tan
+// pow(x, y) min(x, ...) max(x, ...)
+// if(cond, then, else) isnan(x) coalesce(x, ...)
+//
+// The constants NaN, Inf, Pi and E are also available.
+// NaN propagates through arithmetic and comparisons
+// with NaN are false. if returns NaN when cond is NaN
+// and coalesce returns its first argument which is
+// not NaN.
+//
+// Example:
+// (temp_f - 32) * 5 / 9
+// if(duration > 0, distance / duration, NaN)
+type Expr struct {
+	src  string
+	root node
+}
+
+// ParseExpr compiles an expression
+func ParseExpr(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	root, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("Unexpected %q in expression: %s", tok.text, src)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e Expr) String() string {
+	return e.src
+}
+
+// Columns returns the names of all columns
+// referenced in the expression
+func (e Expr) Columns() []string {
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	var walk func(node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case columnNode:
+			if !seen[string(n)] {
+				seen[string(n)] = true
+				columns = append(columns, string(n))
+			}
+		case unaryNode:
+			walk(n.x)
+		case binaryNode:
+			walk(n.x)
+			walk(n.y)
+		case callNode:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(e.root)
+	return columns
+}
+
+// Eval evaluates the expression for each row
+// of the dataset returning one value per row
+func (e Expr) Eval(ds *Dataset) ([]float64, error) {
+	fn, err := e.root.compile(ds.Columns)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]float64, ds.Len())
+	for i := range values {
+		values[i] = fn(ds.Mtx.RawRowView(i))
+	}
+	return values, nil
+}
+
+type evalFn func(row []float64) float64
+
+type node interface {
+	compile(columns []string) (evalFn, error)
+}
+
+type numberNode float64
+
+func (n numberNode) compile([]string) (evalFn, error) {
+	value := float64(n)
+	return func([]float64) float64 { return value }, nil
+}
+
+type columnNode string
+
+func (n columnNode) compile(columns []string) (evalFn, error) {
+	for i, column := range columns {
+		if column == string(n) {
+			return func(row []float64) float64 { return row[i] }, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown column in expression: %s", string(n))
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) compile(columns []string) (evalFn, error) {
+	x, err := n.x.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return func(row []float64) float64 { return truth(!isTrue(x(row)), x(row)) }, nil
+	}
+	return func(row []float64) float64 { return -x(row) }, nil
+}
+
+type binaryNode struct {
+	op   string
+	x, y node
+}
+
+func (n binaryNode) compile(columns []string) (evalFn, error) {
+	x, err := n.x.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.y.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+	var op func(a, b float64) float64
+	switch n.op {
+	case "+":
+		op = func(a, b float64) float64 { return a + b }
+	case "-":
+		op = func(a, b float64) float64 { return a - b }
+	case "*":
+		op = func(a, b float64) float64 { return a * b }
+	case "/":
+		op = func(a, b float64) float64 { return a / b }
+	case "%":
+		op = math.Mod
+	case "^":
+		op = math.Pow
+	case "<":
+		op = func(a, b float64) float64 { return boolValue(a < b) }
+	case "<=":
+		op = func(a, b float64) float64 { return boolValue(a <= b) }
+	case ">":
+		op = func(a, b float64) float64 { return boolValue(a > b) }
+	case ">=":
+		op = func(a, b float64) float64 { return boolValue(a >= b) }
+	case "==":
+		op = func(a, b float64) float64 { return boolValue(a == b) }
+	case "!=":
+		op = func(a, b float64) float64 { return boolValue(a != b && !math.IsNaN(a) && !math.IsNaN(b)) }
+	case "&&":
+		op = func(a, b float64) float64 { return boolValue(isTrue(a) && isTrue(b)) }
+	case "||":
+		op = func(a, b float64) float64 { return boolValue(isTrue(a) || isTrue(b)) }
+	default:
+		return nil, fmt.Errorf("Unknown operator: %s", n.op)
+	}
+	return func(row []float64) float64 { return op(x(row), y(row)) }, nil
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+// exprFuncs contains each function available
+// in expressions and its number of arguments,
+// negative if it accepts one or more
+var exprFuncs = map[string]struct {
+	args int
+	fn   func(args []float64) float64
+}{
+	"abs":   {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"ceil":  {1, func(a []float64) float64 { return math.Ceil(a[0]) }},
+	"floor": {1, func(a []float64) float64 { return math.Floor(a[0]) }},
+	"round": {1, func(a []float64) float64 { return math.Round(a[0]) }},
+	"sqrt":  {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"exp":   {1, func(a []float64) float64 { return math.Exp(a[0]) }},
+	"log":   {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"log2":  {1, func(a []float64) float64 { return math.Log2(a[0]) }},
+	"log10": {1, func(a []float64) float64 { return math.Log10(a[0]) }},
+	"sin":   {1, func(a []float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a []float64) float64 { return math.Cos(a[0]) }},
+	"tan":   {1, func(a []float64) float64 { return math.Tan(a[0]) }},
+	"isnan": {1, func(a []float64) float64 { return boolValue(math.IsNaN(a[0])) }},
+	"pow":   {2, func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"if": {3, func(a []float64) float64 {
+		if math.IsNaN(a[0]) {
+			return math.NaN()
+		}
+		if isTrue(a[0]) {
+			return a[1]
+		}
+		return a[2]
+	}},
+	"min": {-1, func(a []float64) float64 {
+		value := a[0]
+		for _, v := range a[1:] {
+			value = math.Min(value, v)
+		}
+		return value
+	}},
+	"max": {-1, func(a []float64) float64 {
+		value := a[0]
+		for _, v := range a[1:] {
+			value = math.Max(value, v)
+		}
+		return value
+	}},
+	"coalesce": {-1, func(a []float64) float64 {
+		for _, v := range a {
+			if !math.IsNaN(v) {
+				return v
+			}
+		}
+		return math.NaN()
+	}},
+}
+
+func (n callNode) compile(columns []string) (evalFn, error) {
+	fns := make([]evalFn, len(n.args))
+	for i, arg := range n.args {
+		fn, err := arg.compile(columns)
+		if err != nil {
+			return nil, err
+		}
+		fns[i] = fn
+	}
+	f := exprFuncs[n.name].fn
+	return func(row []float64) float64 {
+		args := make([]float64, len(fns))
+		for i, fn := range fns {
+			args[i] = fn(row)
+		}
+		return f(args)
+	}, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
+
+func isTrue(v float64) bool {
+	return v != 0 && !math.IsNaN(v)
+}
+
+// truth returns NaN if v is NaN otherwise b as a value
+func truth(b bool, v float64) float64 {
+	if math.IsNaN(v) {
+		return v
+	}
+	return boolValue(b)
+}
+
+const (
+	tokEOF = iota
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind int
+	text string
+}
+
+func lex(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			// Exponent such as 1e-3
+			if j < len(runes) && (runes[j] == 'e' || runes[j] == 'E') {
+				k := j + 1
+				if k < len(runes) && (runes[k] == '+' || runes[k] == '-') {
+					k++
+				}
+				if k < len(runes) && unicode.IsDigit(runes[k]) {
+					for j = k; j < len(runes) && unicode.IsDigit(runes[j]); j++ {
+					}
+				}
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[i:j])})
+			i = j
+		case r == '`':
+			j := i + 1
+			for j < len(runes) && runes[j] != '`' {
+				j++
+			}
+			if j == len(runes) {
+				return nil, fmt.Errorf("Unterminated column name in expression: %s", src)
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: "`" + string(runes[i+1:j])})
+			i = j + 1
+		default:
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					tokens = append(tokens, token{kind: tokOp, text: two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("+-*/%^<>!(),", r) {
+				return nil, fmt.Errorf("Unexpected %q in expression: %s", r, src)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: string(r)})
+			i++
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+// Binding power of binary operators
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+	"^": 8,
+}
+
+// Binding power of unary operators, below ^ so
+// that -2^2 is -(2^2)
+const unaryPrecedence = 7
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) expect(text string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != text {
+		return fmt.Errorf("Expected %q but found %q in expression", text, tok.text)
+	}
+	return nil
+}
+
+// parse parses operators which bind tighter than min
+func (p *exprParser) parse(min int) (node, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		prec, ok := precedence[tok.text]
+		if tok.kind != tokOp || !ok || prec <= min {
+			return left, nil
+		}
+		p.next()
+		// ^ is right associative
+		if tok.text == "^" {
+			prec--
+		}
+		right, err := p.parse(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: tok.text, x: left, y: right}
+	}
+}
+
+func (p *exprParser) operand() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad number in expression: %s", tok.text)
+		}
+		return numberNode(value), nil
+	case tokIdent:
+		if strings.HasPrefix(tok.text, "`") {
+			return columnNode(tok.text[1:]), nil
+		}
+		if next := p.peek(); next.kind == tokOp && next.text == "(" {
+			return p.call(tok.text)
+		}
+		switch tok.text {
+		case "NaN":
+			return numberNode(math.NaN()), nil
+		case "Inf":
+			return numberNode(math.Inf(1)), nil
+		case "Pi":
+			return numberNode(math.Pi), nil
+		case "E":
+			return numberNode(math.E), nil
+		}
+		return columnNode(tok.text), nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			n, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "-", "!", "+":
+			x, err := p.parse(unaryPrecedence)
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == "+" {
+				return x, nil
+			}
+			return unaryNode{op: tok.text, x: x}, nil
+		}
+	}
+	if tok.kind == tokEOF {
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+	return nil, fmt.Errorf("Unexpected %q in expression", tok.text)
+}
+
+func (p *exprParser) call(name string) (node, error) {
+	fn, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown function in expression: %s", name)
+	}
+	p.next() // (
+	call := callNode{name: name}
+	if tok := p.peek(); !(tok.kind == tokOp && tok.text == ")") {
+		for {
+			arg, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if tok := p.peek(); tok.kind == tokOp && tok.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if (fn.args < 0 && len(call.args) == 0) || (fn.args >= 0 && len(call.args) != fn.args) {
+		return nil, fmt.Errorf("Wrong number of arguments to %s", name)
+	}
+	return call, nil
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestExpr(t *testing.T) {
+	ds := &Dataset{
+		Columns: []string{"x", "y", "temp f"},
+		Mtx:     mtx.NewDense(3, 3, []float64{1, 2, 212, 4, 0, 32, math.NaN(), 3, 50}),
+	}
+	for src, expected := range map[string][]float64{
+		"x + y * 2":                      {5, 4, math.NaN()},
+		"(x + y) * 2":                    {6, 8, math.NaN()},
+		"-2^2 + x":                       {-3, 0, math.NaN()},
+		"2^3^2":                          {512, 512, 512},
+		"(`temp f` - 32) * 5 / 9":        {100, 0, 10},
+		"x > 1 && y >= 0":                {0, 1, 0},
+		"x == 1 || !y":                   {1, 1, 0},
+		"if(y > 0, x / y, NaN)":          {0.5, math.NaN(), math.NaN()},
+		"coalesce(x, y)":                 {1, 4, 3},
+		"isnan(x)":                       {0, 0, 1},
+		"max(x, y, 3) + abs(-1) + 1e-1":  {4.1, 5.1, math.NaN()},
+		"round(sqrt(y * 8)) % 3":         {1, 0, 2},
+		"round(x * -2.5)":                {-3, -10, math.NaN()},
+		"round(0.49999999999999994)":     {0, 0, 0},
+		"pow(2, y) - floor(log10(1000))": {1, -2, 5},
+	} {
+		expr, err := ParseExpr(src)
+		assert.NoError(t, err, src)
+		values, err := expr.Eval(ds)
+		assert.NoError(t, err, src)
+		for i, value := range values {
+			if math.IsNaN(expected[i]) {
+				assert.True(t, math.IsNaN(value), src)
+			} else {
+				assert.InDelta(t, expected[i], value, 1e-9, src)
+			}
+		}
+	}
+	expr, err := ParseExpr("if(x > y, `temp f`, x)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y", "temp f"}, expr.Columns())
+	for _, src := range []string{"", "x +", "(x", "x y", "nope(x)", "pow(x)", "min()", "x $ y", "`x"} {
+		_, err := ParseExpr(src)
+		assert.Error(t, err, src)
+	}
+	expr, err = ParseExpr("z + 1")
+	assert.NoError(t, err)
+	_, err = expr.Eval(ds)
+	assert.Error(t, err)
+}
+
+func TestQueryDerived(t *testing.T) {
+	ds := &Dataset{
+		Columns: []string{"distance", "duration"},
+		Meta:    []Meta{{Unit: "km"}},
+		Mtx:     mtx.NewDense(2, 2, []float64{10, 2, 9, 3}),
+	}
+	query := NewQuery([]string{"D0,distance,duration"}, "", "")
+	query.Derived = append(query.Derived, NewDerived("speed=distance / duration"))
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"distance", "duration", "speed"}, ds.Columns)
+	assert.Len(t, ds.Meta, 3)
+	assert.Equal(t, 5.0, ds.Mtx.At(0, 2))
+	assert.Equal(t, 3.0, ds.Mtx.At(1, 2))
+	assert.Error(t, query.Apply(ds))
+	query.Derived = []Derived{NewDerived("missing")}
+	assert.Error(t, query.Apply(ds))
+}
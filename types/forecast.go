@@ -0,0 +1,249 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Forecast extends a column with exponential smoothing.
+// Horizon rows are appended to the dataset with the
+// time column at Index continued at the interval of
+// its last two values. Three columns are added, the
+// forecast with the lower and upper bounds of its 95%
+// prediction interval. They are NaN for the observed
+// rows except for the last which repeats the observed
+// value so the forecast continues from it. Smoothing
+// parameters are chosen to minimize the squared one
+// step ahead errors.
+//
+// simple        level only
+// holt          level and trend
+// holt-winters  level, trend and additive seasonality of Period rows
+type Forecast struct {
+	Method  string
+	Column  string
+	Horizon int
+	Period  int // Rows in each season for holt-winters
+	Index   int // Index of the time column
+}
+
+func (fc Forecast) String() string {
+	str := fmt.Sprintf("%s,%s,%d", fc.Method, fc.Column, fc.Horizon)
+	if fc.Method == "holt-winters" || fc.Index != 0 {
+		str += fmt.Sprintf(",%d", fc.Period)
+	}
+	if fc.Index != 0 {
+		str += fmt.Sprintf(",%d", fc.Index)
+	}
+	return str
+}
+
+// Columns returns the names of the added columns
+func (fc Forecast) Columns() []string {
+	return []string{fc.Column + "_forecast", fc.Column + "_lower", fc.Column + "_upper"}
+}
+
+// smoothing is the state of a fitted model
+type smoothing struct {
+	alpha, beta, gamma float64
+	level, trend       float64
+	season             []float64
+	sse                float64
+	n                  int // Number of one step errors in sse
+}
+
+// smooth fits the model to values with the given
+// parameters. Holt-Winters is initialized from the
+// first two seasons.
+func (fc Forecast) smooth(values []float64, alpha, beta, gamma float64) *smoothing {
+	s := &smoothing{alpha: alpha, beta: beta, gamma: gamma, level: values[0]}
+	start := 1
+	switch fc.Method {
+	case "holt":
+		s.trend = values[1] - values[0]
+	case "holt-winters":
+		m := fc.Period
+		first, second := 0.0, 0.0
+		for i := 0; i < m; i++ {
+			first += values[i] / float64(m)
+			second += values[i+m] / float64(m)
+		}
+		s.level, s.trend = first, (second-first)/float64(m)
+		s.season = make([]float64, m)
+		for i := 0; i < m; i++ {
+			s.season[i] = values[i] - first
+		}
+		start = m
+	}
+	for t := start; t < len(values); t++ {
+		seasonal := 0.0
+		if s.season != nil {
+			seasonal = s.season[t%fc.Period]
+		}
+		e := values[t] - (s.level + s.trend + seasonal)
+		s.sse += e * e
+		s.n++
+		level := s.level
+		s.level = alpha*(values[t]-seasonal) + (1-alpha)*(s.level+s.trend)
+		if fc.Method != "simple" {
+			s.trend = beta*(s.level-level) + (1-beta)*s.trend
+		}
+		if s.season != nil {
+			s.season[t%fc.Period] = gamma*(values[t]-s.level) + (1-gamma)*seasonal
+		}
+	}
+	return s
+}
+
+// fit searches for the smoothing parameters with
+// the smallest squared one step errors
+func (fc Forecast) fit(values []float64) *smoothing {
+	grid := make([]float64, 0, 19)
+	for p := 0.05; p < 1; p += 0.05 {
+		grid = append(grid, p)
+	}
+	betas, gammas := []float64{0}, []float64{0}
+	if fc.Method != "simple" {
+		betas = grid
+	}
+	if fc.Method == "holt-winters" {
+		gammas = grid
+	}
+	var best *smoothing
+	for _, alpha := range grid {
+		for _, beta := range betas {
+			for _, gamma := range gammas {
+				if s := fc.smooth(values, alpha, beta, gamma); best == nil || s.sse < best.sse {
+					best = s
+				}
+			}
+		}
+	}
+	return best
+}
+
+// Values returns the forecast with the lower and
+// upper bounds of its prediction interval for each
+// step of the horizon
+func (fc Forecast) Values(values []float64) ([]float64, []float64, []float64, error) {
+	minimum := 2
+	switch fc.Method {
+	case "simple", "holt":
+	case "holt-winters":
+		if fc.Period < 2 {
+			return nil, nil, nil, fmt.Errorf("Bad forecast period: %d", fc.Period)
+		}
+		minimum = 2*fc.Period + 1
+	default:
+		return nil, nil, nil, fmt.Errorf("Unknown forecast method: %s", fc.Method)
+	}
+	if fc.Horizon < 1 {
+		return nil, nil, nil, fmt.Errorf("Bad forecast horizon: %d", fc.Horizon)
+	}
+	if len(values) < minimum {
+		return nil, nil, nil, fmt.Errorf("Forecast %s needs at least %d values", fc.Method, minimum)
+	}
+	for _, value := range values {
+		if math.IsNaN(value) {
+			return nil, nil, nil, fmt.Errorf("Forecast column %s has missing values, fill them first", fc.Column)
+		}
+	}
+	s := fc.fit(values)
+	sigma := math.Sqrt(s.sse / float64(s.n))
+	forecast := make([]float64, fc.Horizon)
+	lower := make([]float64, fc.Horizon)
+	upper := make([]float64, fc.Horizon)
+	variance := 0.0
+	for h := 1; h <= fc.Horizon; h++ {
+		forecast[h-1] = s.level + float64(h)*s.trend
+		if s.season != nil {
+			forecast[h-1] += s.season[(len(values)+h-1)%fc.Period]
+		}
+		// Variance grows with each step by the weight
+		// the previous errors carry into the forecast
+		c := 0.0
+		if h > 1 {
+			j := float64(h - 1)
+			c = s.alpha + s.alpha*s.beta*j
+			if s.season != nil && (h-1)%fc.Period == 0 {
+				c += s.gamma * (1 - s.alpha)
+			}
+		}
+		variance += c * c
+		width := 1.96 * sigma * math.Sqrt(1+variance)
+		lower[h-1], upper[h-1] = forecast[h-1]-width, forecast[h-1]+width
+	}
+	return forecast, lower, upper, nil
+}
+
+// Apply appends the forecast rows and columns to the dataset
+func (fc Forecast) Apply(ds *Dataset) error {
+	column := ds.CPos(fc.Column)
+	if column < 0 {
+		return fmt.Errorf("Forecast column not found: %s", fc.Column)
+	}
+	r, c := ds.Mtx.Dims()
+	if fc.Index < 0 || fc.Index >= c || fc.Index == column {
+		return fmt.Errorf("Bad forecast time column: %d", fc.Index)
+	}
+	values := make([]float64, r)
+	for i := range values {
+		values[i] = ds.Mtx.At(i, column)
+	}
+	forecast, lower, upper, err := fc.Values(values)
+	if err != nil {
+		return err
+	}
+	last := ds.Mtx.At(r-1, fc.Index)
+	step := last - ds.Mtx.At(r-2, fc.Index)
+	rows := make([]float64, 0, (r+fc.Horizon)*c)
+	rows = append(rows, ds.Mtx.RawMatrix().Data...)
+	extended := make([]float64, r+fc.Horizon)
+	for i := range extended {
+		extended[i] = math.NaN()
+	}
+	for h := 1; h <= fc.Horizon; h++ {
+		row := nans(c)
+		row[fc.Index] = last + float64(h)*step
+		rows = append(rows, row...)
+	}
+	ds.Mtx = mtx.NewDense(r+fc.Horizon, c, rows)
+	meta := ds.ColumnMeta(column)
+	for i, result := range [][]float64{forecast, lower, upper} {
+		values := append([]float64(nil), extended...)
+		values[r-1] = ds.Mtx.At(r-1, column)
+		copy(values[r:], result)
+		ds.AddColumn(fc.Columns()[i], values, meta)
+	}
+	return nil
+}
+
+// NewForecast returns a Forecast from a string
+// parameter, INDEX defaults to 0
+//
+// holt,value,10
+// holt-winters,value,24,12,0
+// ^------------^-----^--^--^---Method,Column,Horizon,Period,Index
+func NewForecast(arg string) *Forecast {
+	split := strings.Split(arg, ",")
+	forecast := &Forecast{Method: strings.ToLower(split[0])}
+	if len(split) >= 2 {
+		forecast.Column = split[1]
+	}
+	if len(split) >= 3 {
+		horizon, _ := strconv.ParseInt(split[2], 0, 64)
+		forecast.Horizon = int(horizon)
+	}
+	if len(split) >= 4 {
+		period, _ := strconv.ParseInt(split[3], 0, 64)
+		forecast.Period = int(period)
+	}
+	if len(split) >= 5 {
+		index, _ := strconv.ParseInt(split[4], 0, 64)
+		forecast.Index = int(index)
+	}
+	return forecast
+}
@@ -0,0 +1,62 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestForecast(t *testing.T) {
+	forecast := NewForecast("holt-winters,value,4,4")
+	assert.Equal(t, "holt-winters,value,4,4", forecast.String())
+	assert.Equal(t, "holt,value,2,0,1", Forecast{Method: "holt", Column: "value", Horizon: 2, Index: 1}.String())
+	// Trend of 1 per row with a season of 4 rows
+	season := []float64{2, -1, 0, -1}
+	values := make([]float64, 24)
+	for i := range values {
+		values[i] = float64(i) + season[i%4]
+	}
+	result, lower, upper, err := forecast.Values(values)
+	assert.NoError(t, err)
+	for h := range result {
+		expected := float64(24+h) + season[(24+h)%4]
+		assert.InDelta(t, expected, result[h], 0.5)
+		assert.True(t, lower[h] <= result[h] && result[h] <= upper[h])
+		if h > 0 {
+			assert.True(t, upper[h]-lower[h] >= upper[h-1]-lower[h-1])
+		}
+	}
+	result, _, _, err = NewForecast("holt,value,3").Values([]float64{1, 2, 3, 4, 5, 6})
+	assert.NoError(t, err)
+	assert.InDelta(t, 7, result[0], 1e-6)
+	assert.InDelta(t, 9, result[2], 1e-6)
+	result, _, _, err = NewForecast("simple,value,2").Values([]float64{5, 5, 5, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{5, 5}, result)
+	for _, arg := range []string{"nope,value,2", "simple,value,0", "holt-winters,value,2,1", "holt-winters,value,2,4"} {
+		_, _, _, err = NewForecast(arg).Values(values[:8])
+		assert.Error(t, err, arg)
+	}
+	_, _, _, err = NewForecast("simple,value,2").Values([]float64{1, math.NaN()})
+	assert.Error(t, err)
+}
+
+func TestQueryForecast(t *testing.T) {
+	query := NewQuery([]string{"D0,time,value"}, "", "")
+	query.Forecast = NewForecast("holt,value,2")
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Mtx:     mtx.NewDense(4, 2, []float64{10, 1, 20, 2, 30, 3, 40, 4}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"time", "value", "value_forecast", "value_lower", "value_upper"}, ds.Columns)
+	assert.Equal(t, []float64{10, 20, 30, 40, 50, 60}, mtx.Col(nil, 0, ds.Mtx))
+	forecast := mtx.Col(nil, 2, ds.Mtx)
+	assert.True(t, math.IsNaN(forecast[0]))
+	assert.Equal(t, 4.0, forecast[3])
+	assert.InDelta(t, 5, forecast[4], 1e-6)
+	assert.True(t, math.IsNaN(ds.Mtx.At(5, 1)))
+	query.Forecast = NewForecast("holt,nope,2")
+	assert.Error(t, query.Apply(ds))
+}
@@ -2,6 +2,7 @@ package types
 
 import (
 	mtx "github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
 	"strings"
 )
 
@@ -37,6 +38,10 @@ func (fn Function) Apply(mx []mtx.Matrix) *mtx.Dense {
 		return fn.apply(mx, mtx.Max)
 	case "sum":
 		return fn.apply(mx, mtx.Sum)
+	case "std":
+		return fn.apply(mx, func(o mtx.Matrix) float64 {
+			return stat.StdDev(mtx.Col(nil, 0, o), nil)
+		})
 	default: // Use the average
 		if len(mx) > 0 {
 			return fn.apply(mx, func(o mtx.Matrix) float64 {
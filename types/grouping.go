@@ -17,9 +17,10 @@ type grouping struct {
 
 // Grouping represents a "group by" configuration
 type Grouping struct {
-	Name  string
-	Index int
-	Max   time.Duration
+	Name      string
+	Index     int
+	Max       time.Duration
+	Precision Precision // Precision of the time values at Index
 }
 
 func (grp Grouping) UnmarshalJSON(data []byte) error {
@@ -57,7 +58,7 @@ func (grp Grouping) Group(other *mtx.Dense) []mtx.Matrix {
 	)
 	for i, j := 0, 1; i+j <= r; j++ {
 		view = other.View(i, 0, j, c)
-		current = time.Unix(int64(view.At(j-1, grp.Index)), 0).UTC()
+		current = grp.Precision.Time(view.At(j-1, grp.Index))
 		duration += current.Sub(previous)
 		if duration >= grp.Max {
 			views = append(views, view)
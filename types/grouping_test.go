@@ -53,6 +53,25 @@ func TestGrouping(t *testing.T) {
 	//fmt.Println(mtx.Formatted(mx))
 }
 
+func TestGroupingPrecision(t *testing.T) {
+	grouping := NewGrouping("Duration,0,1s")
+	grouping.Precision = Milliseconds
+	mx := mtx.NewDense(10, 2, nil)
+	start := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		mx.Set(i, 0, Milliseconds.Value(start))
+		mx.Set(i, 1, 1.0)
+		start = start.Add(250 * time.Millisecond)
+	}
+	views := grouping.Group(mx)
+	// The first row is always grouped alone
+	assert.Len(t, views, 3)
+	r, _ := views[1].Dims()
+	assert.Equal(t, 4, r)
+	r, _ = views[2].Dims()
+	assert.Equal(t, 4, r)
+}
+
 func init() {
 	rand.Seed(time.Now().Unix())
 }
@@ -0,0 +1,185 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/gonum/stat"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Histogram counts the values of a column within bins.
+// Each bin includes its start and excludes its end
+// except for the last bin. Missing values and values
+// outside of the bins are not counted.
+//
+// width: Bins of equal width between the smallest and
+// largest values.
+// quantile: Bins containing an equal number of values.
+// edges: Bins between each of the explicit Edges.
+type Histogram struct {
+	Method  string
+	Column  string
+	Bins    int
+	Edges   []float64
+	Density bool // Divide counts by the total and bin width
+}
+
+func (h Histogram) String() string {
+	args := []string{h.Method, h.Column}
+	if h.Method == "edges" {
+		for _, edge := range h.Edges {
+			args = append(args, strconv.FormatFloat(edge, 'g', -1, 64))
+		}
+	} else {
+		args = append(args, strconv.Itoa(h.Bins))
+	}
+	if h.Density {
+		args = append(args, "density")
+	}
+	return strings.Join(args, ",")
+}
+
+// Columns returns the names of the resulting columns
+func (h Histogram) Columns() []string {
+	if h.Density {
+		return []string{"bin_start", "bin_end", "density"}
+	}
+	return []string{"bin_start", "bin_end", "count"}
+}
+
+// edges returns the ascending edges of each bin
+func (h Histogram) edges(sorted []float64) ([]float64, error) {
+	switch h.Method {
+	case "width", "quantile":
+		if h.Bins < 1 {
+			return nil, fmt.Errorf("Bad histogram bins: %d", h.Bins)
+		}
+		if len(sorted) == 0 {
+			return nil, fmt.Errorf("Histogram requires at least one value")
+		}
+	case "edges":
+		if len(h.Edges) < 2 {
+			return nil, fmt.Errorf("Histogram requires at least two edges")
+		}
+		for i := 1; i < len(h.Edges); i++ {
+			if h.Edges[i] <= h.Edges[i-1] {
+				return nil, fmt.Errorf("Histogram edges must be ascending")
+			}
+		}
+		return h.Edges, nil
+	default:
+		return nil, fmt.Errorf("Unknown histogram method: %s", h.Method)
+	}
+	min, max := sorted[0], sorted[len(sorted)-1]
+	if min == max {
+		min, max = min-0.5, max+0.5
+	}
+	edges := []float64{min}
+	for i := 1; i <= h.Bins; i++ {
+		var edge float64
+		switch {
+		case i == h.Bins:
+			edge = max
+		case h.Method == "width":
+			edge = min + float64(i)*(max-min)/float64(h.Bins)
+		default:
+			edge = stat.Quantile(float64(i)/float64(h.Bins), stat.Empirical, sorted, nil)
+		}
+		// Quantiles of repeated values share an edge
+		if edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+	return edges, nil
+}
+
+// Values returns the edges of each bin and
+// the count (or density) of values within it
+func (h Histogram) Values(values []float64) (edges, counts []float64, err error) {
+	sorted := present(values)
+	sort.Float64s(sorted)
+	if edges, err = h.edges(sorted); err != nil {
+		return nil, nil, err
+	}
+	counts = make([]float64, len(edges)-1)
+	var total float64
+	for _, value := range sorted {
+		if value < edges[0] || value > edges[len(edges)-1] {
+			continue
+		}
+		// Index of the first edge greater than the value
+		i := sort.SearchFloat64s(edges, value)
+		if i < len(edges) && edges[i] == value {
+			i++
+		}
+		if i > len(counts) {
+			i = len(counts)
+		}
+		counts[i-1]++
+		total++
+	}
+	if h.Density && total > 0 {
+		for i := range counts {
+			counts[i] /= total * (edges[i+1] - edges[i])
+		}
+	}
+	return edges, counts, nil
+}
+
+// Apply replaces the values of the dataset
+// with the start, end and count of each bin
+func (h Histogram) Apply(ds *Dataset) error {
+	column := ds.CPos(h.Column)
+	if column < 0 {
+		return fmt.Errorf("Histogram column not found: %s", h.Column)
+	}
+	meta := ds.ColumnMeta(column)
+	edges, counts, err := h.Values(mtx.Col(nil, column, ds.Mtx))
+	if err != nil {
+		return err
+	}
+	mx := mtx.NewDense(len(counts), 3, nil)
+	for i, count := range counts {
+		mx.SetRow(i, []float64{edges[i], edges[i+1], count})
+	}
+	ds.Columns = h.Columns()
+	ds.Mtx = mx
+	// Bin edges keep the unit of the column
+	ds.Meta = []Meta{meta, meta, {}}
+	return nil
+}
+
+// NewHistogram returns a Histogram from a string
+// parameter, edges are listed after the column and
+// a final density argument divides the counts
+//
+// width,value,10
+// quantile,value,4,density
+// edges,value,0,10,20,50
+// ^-----^-----^----------Method,Column,Bins|Edges...
+func NewHistogram(arg string) *Histogram {
+	split := strings.Split(arg, ",")
+	histogram := &Histogram{
+		Method: strings.ToLower(split[0]),
+		Bins:   10,
+	}
+	if last := strings.ToLower(split[len(split)-1]); last == "density" || last == "count" {
+		histogram.Density = last == "density"
+		split = split[:len(split)-1]
+	}
+	if len(split) >= 2 {
+		histogram.Column = split[1]
+	}
+	if histogram.Method == "edges" && len(split) >= 3 {
+		for _, value := range split[2:] {
+			edge, _ := strconv.ParseFloat(value, 64)
+			histogram.Edges = append(histogram.Edges, edge)
+		}
+	} else if len(split) >= 3 {
+		bins, _ := strconv.ParseInt(split[2], 0, 64)
+		histogram.Bins = int(bins)
+	}
+	return histogram
+}
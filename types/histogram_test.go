@@ -0,0 +1,51 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestHistogram(t *testing.T) {
+	values := []float64{0, 1, 2, 2, 3, math.NaN(), 4, 8}
+	edges, counts, err := NewHistogram("width,v,4").Values(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 2, 4, 6, 8}, edges)
+	assert.Equal(t, []float64{2, 3, 1, 1}, counts)
+	edges, counts, err = NewHistogram("width,v,4,density").Values(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{2 / 14.0, 3 / 14.0, 1 / 14.0, 1 / 14.0}, counts)
+	edges, counts, err = NewHistogram("quantile,v,2").Values(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0, 2, 8}, edges)
+	assert.Equal(t, []float64{2, 5}, counts)
+	edges, counts, err = NewHistogram("edges,v,1,3,10").Values(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 3, 10}, edges)
+	assert.Equal(t, []float64{3, 3}, counts)
+	edges, counts, err = NewHistogram("width,v,2").Values([]float64{5, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{4.5, 5, 5.5}, edges)
+	assert.Equal(t, []float64{0, 2}, counts)
+	for _, arg := range []string{"nope,v", "width,v,0", "edges,v,1", "edges,v,2,1"} {
+		_, _, err = NewHistogram(arg).Values(values)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestQueryHistogram(t *testing.T) {
+	query := NewQuery([]string{"D0,value"}, "", "")
+	query.Histogram = NewHistogram("edges,value,0,5,10,density")
+	assert.Equal(t, "edges,value,0,5,10,density", query.Histogram.String())
+	ds := &Dataset{
+		Columns: []string{"value"},
+		Meta:    []Meta{{Unit: "km"}},
+		Mtx:     mtx.NewDense(4, 1, []float64{1, 2, 6, 10}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"bin_start", "bin_end", "density"}, ds.Columns)
+	assert.Equal(t, "km", ds.ColumnMeta(0).Unit)
+	assert.Equal(t, []float64{0, 5, 0.1}, mtx.Row(nil, 0, ds.Mtx))
+	assert.Equal(t, []float64{5, 10, 0.1}, mtx.Row(nil, 1, ds.Mtx))
+}
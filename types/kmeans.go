@@ -0,0 +1,212 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// MaxKMeansIterations is the most times rows
+// are assigned to clusters before giving up
+const MaxKMeansIterations = 300
+
+// KMeans partitions the rows of a dataset into K
+// clusters minimising the squared distance of each
+// row to the centroid of its cluster. Centroids are
+// initialised with k-means++ from a random Seed so
+// results are reproducible.
+type KMeans struct {
+	K       int
+	Columns []string // Columns to cluster on, default all
+	Seed    int64
+}
+
+func (km KMeans) String() string {
+	return strings.Join(append([]string{strconv.Itoa(km.K)}, km.Columns...), ",")
+}
+
+// Clusters is the result of KMeans
+type Clusters struct {
+	Labels     []float64   // Cluster of each row, NaN for rows with missing values
+	Centroids  [][]float64 // Mean of each column for each cluster
+	Sizes      []int       // Number of rows in each cluster
+	Inertia    float64     // Sum of squared distances to each centroid
+	Iterations int
+}
+
+// selectColumns returns the columns at each position of mx
+func selectColumns(mx *mtx.Dense, columns []int) *mtx.Dense {
+	r, _ := mx.Dims()
+	out := mtx.NewDense(r, len(columns), nil)
+	for i := 0; i < r; i++ {
+		for j, column := range columns {
+			out.Set(i, j, mx.At(i, column))
+		}
+	}
+	return out
+}
+
+// selection returns the named columns
+// of the dataset, all when empty
+func selection(ds *Dataset, columns []string) (*mtx.Dense, error) {
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	pos, err := positions(ds, columns)
+	if err != nil {
+		return nil, err
+	}
+	return selectColumns(ds.Mtx, pos), nil
+}
+
+// complete returns the position of each
+// row of mx without missing values
+func complete(mx *mtx.Dense) []int {
+	r, c := mx.Dims()
+	rows := make([]int, 0, r)
+	for i := 0; i < r; i++ {
+		missing := false
+		for j := 0; j < c; j++ {
+			missing = missing || math.IsNaN(mx.At(i, j))
+		}
+		if !missing {
+			rows = append(rows, i)
+		}
+	}
+	return rows
+}
+
+// distance returns the squared euclidean distance
+func distance(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	return sum
+}
+
+// nearestCentroid returns the closest centroid and its distance
+func nearestCentroid(row []float64, centroids [][]float64) (int, float64) {
+	best, min := 0, math.Inf(1)
+	for i, centroid := range centroids {
+		if d := distance(row, centroid); d < min {
+			best, min = i, d
+		}
+	}
+	return best, min
+}
+
+// Fit clusters the rows of mx ignoring
+// rows with missing values
+func (km KMeans) Fit(mx *mtx.Dense) (*Clusters, error) {
+	rows := complete(mx)
+	if km.K < 1 || km.K > len(rows) {
+		return nil, fmt.Errorf("Bad number of clusters %d for %d rows", km.K, len(rows))
+	}
+	points := make([][]float64, len(rows))
+	for i, row := range rows {
+		points[i] = mx.RawRowView(row)
+	}
+	// k-means++ chooses each centroid with a probability
+	// proportional to its distance from previous centroids
+	random := rand.New(rand.NewSource(km.Seed))
+	centroids := [][]float64{append([]float64(nil), points[random.Intn(len(points))]...)}
+	for len(centroids) < km.K {
+		distances := make([]float64, len(points))
+		var total float64
+		for i, point := range points {
+			_, distances[i] = nearestCentroid(point, centroids)
+			total += distances[i]
+		}
+		choice, target := len(points)-1, random.Float64()*total
+		for i, d := range distances {
+			if target -= d; target < 0 {
+				choice = i
+				break
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), points[choice]...))
+	}
+	labels := make([]int, len(points))
+	for i := range labels {
+		labels[i] = -1
+	}
+	clusters := &Clusters{}
+	for clusters.Iterations < MaxKMeansIterations {
+		clusters.Iterations++
+		changed := false
+		for i, point := range points {
+			if label, _ := nearestCentroid(point, centroids); label != labels[i] {
+				labels[i], changed = label, true
+			}
+		}
+		if !changed {
+			break
+		}
+		// Move each centroid to the mean of its rows,
+		// empty clusters keep their previous centroid
+		sums, counts := make([][]float64, km.K), make([]int, km.K)
+		for i := range sums {
+			sums[i] = make([]float64, len(centroids[i]))
+		}
+		for i, point := range points {
+			counts[labels[i]]++
+			for j, value := range point {
+				sums[labels[i]][j] += value
+			}
+		}
+		for i := range centroids {
+			for j := range centroids[i] {
+				if counts[i] > 0 {
+					centroids[i][j] = sums[i][j] / float64(counts[i])
+				}
+			}
+		}
+	}
+	r, _ := mx.Dims()
+	clusters.Labels = nans(r)
+	clusters.Centroids = centroids
+	clusters.Sizes = make([]int, km.K)
+	for i, point := range points {
+		clusters.Labels[rows[i]] = float64(labels[i])
+		clusters.Sizes[labels[i]]++
+		clusters.Inertia += distance(point, centroids[labels[i]])
+	}
+	return clusters, nil
+}
+
+// Apply adds the cluster of each row
+// to the dataset as the cluster column
+func (km KMeans) Apply(ds *Dataset) error {
+	mx, err := selection(ds, km.Columns)
+	if err != nil {
+		return err
+	}
+	clusters, err := km.Fit(mx)
+	if err != nil {
+		return err
+	}
+	ds.AddColumn("cluster", clusters.Labels, Meta{})
+	return nil
+}
+
+// NewKMeans returns KMeans from a string
+// parameter clustering on all columns by default
+//
+// 3,x,y
+// ^-^---K,Columns...
+func NewKMeans(arg string) *KMeans {
+	split := strings.Split(arg, ",")
+	k, _ := strconv.ParseInt(split[0], 0, 64)
+	kmeans := &KMeans{
+		K:    int(k),
+		Seed: 1,
+	}
+	if len(split) >= 2 {
+		kmeans.Columns = split[1:]
+	}
+	return kmeans
+}
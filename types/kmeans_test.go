@@ -0,0 +1,53 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestKMeans(t *testing.T) {
+	mx := mtx.NewDense(7, 2, []float64{
+		0, 0,
+		0, 1,
+		1, 0,
+		10, 10,
+		10, 11,
+		math.NaN(), 5,
+		11, 10,
+	})
+	clusters, err := NewKMeans("2").Fit(mx)
+	assert.NoError(t, err)
+	assert.True(t, math.IsNaN(clusters.Labels[5]))
+	assert.Equal(t, clusters.Labels[0], clusters.Labels[1])
+	assert.Equal(t, clusters.Labels[0], clusters.Labels[2])
+	assert.Equal(t, clusters.Labels[3], clusters.Labels[4])
+	assert.Equal(t, clusters.Labels[3], clusters.Labels[6])
+	assert.NotEqual(t, clusters.Labels[0], clusters.Labels[3])
+	assert.Equal(t, []int{3, 3}, clusters.Sizes)
+	low := int(clusters.Labels[0])
+	assert.InDeltaSlice(t, []float64{1 / 3.0, 1 / 3.0}, clusters.Centroids[low], 1e-9)
+	assert.InDeltaSlice(t, []float64{31 / 3.0, 31 / 3.0}, clusters.Centroids[1-low], 1e-9)
+	assert.InDelta(t, 8/3.0, clusters.Inertia, 1e-9)
+	for _, arg := range []string{"0", "7"} {
+		_, err = NewKMeans(arg).Fit(mx)
+		assert.Error(t, err, arg)
+	}
+}
+
+func TestQueryKMeans(t *testing.T) {
+	query := NewQuery([]string{"D0,time,x"}, "", "")
+	query.KMeans = NewKMeans("2,x")
+	ds := &Dataset{
+		Columns: []string{"time", "x"},
+		Mtx:     mtx.NewDense(4, 2, []float64{1, 0, 2, 100, 3, 1, 4, 101}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"time", "x", "cluster"}, ds.Columns)
+	labels := mtx.Col(nil, 2, ds.Mtx)
+	assert.Equal(t, labels[0], labels[2])
+	assert.Equal(t, labels[1], labels[3])
+	query.KMeans = NewKMeans("2,nope")
+	assert.Error(t, query.Apply(ds))
+}
@@ -0,0 +1,70 @@
+package types
+
+import (
+	"fmt"
+	"time"
+)
+
+// Precision is the resolution at which time
+// values are stored as the number of units
+// elapsed since the Unix epoch
+type Precision string
+
+const (
+	Seconds      Precision = "s"
+	Milliseconds Precision = "ms"
+	Microseconds Precision = "us"
+	Nanoseconds  Precision = "ns"
+)
+
+// ParsePrecision returns the Precision for
+// s, ms, us or ns
+func ParsePrecision(str string) (Precision, error) {
+	switch p := Precision(str); p {
+	case Seconds, Milliseconds, Microseconds, Nanoseconds:
+		return p, nil
+	}
+	return "", fmt.Errorf("Bad precision: %s", str)
+}
+
+// Unit returns the duration of a single unit,
+// an empty Precision is treated as Seconds
+func (p Precision) Unit() time.Duration {
+	switch p {
+	case Milliseconds:
+		return time.Millisecond
+	case Microseconds:
+		return time.Microsecond
+	case Nanoseconds:
+		return time.Nanosecond
+	}
+	return time.Second
+}
+
+// Time converts a stored value to a UTC time
+func (p Precision) Time(value float64) time.Time {
+	unit := p.Unit()
+	if unit == time.Second {
+		return time.Unix(int64(value), 0).UTC()
+	}
+	return time.Unix(0, int64(value)*int64(unit)).UTC()
+}
+
+// Value converts a time to a stored value.
+// Note that float64 can only represent
+// nanoseconds since the epoch to within
+// a few hundred nanoseconds.
+func (p Precision) Value(t time.Time) float64 {
+	unit := p.Unit()
+	if unit == time.Second {
+		return float64(t.Unix())
+	}
+	return float64(t.UnixNano() / int64(unit))
+}
+
+// Meta contains information describing
+// the values stored within a column
+type Meta struct {
+	Precision Precision `json:",omitempty"` // Precision of time values
+	Unit      string    `json:",omitempty"` // Unit of measurement
+}
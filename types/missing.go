@@ -0,0 +1,165 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Fill replaces missing (NaN) values in a
+// column. The column "*" fills every column.
+//
+// ffill     previous value which is not missing
+// bfill     next value which is not missing
+// constant  Value
+// linear    interpolation by row, leading and trailing values are left missing
+type Fill struct {
+	Method string
+	Column string
+	Value  float64 // Value for the constant method
+}
+
+func (fill Fill) String() string {
+	if fill.Method == "constant" {
+		return fmt.Sprintf("%s,%s,%s", fill.Method, fill.Column, strconv.FormatFloat(fill.Value, 'g', -1, 64))
+	}
+	return fmt.Sprintf("%s,%s", fill.Method, fill.Column)
+}
+
+// Values fills the missing values in place
+func (fill Fill) Values(values []float64) error {
+	switch fill.Method {
+	case "ffill":
+		previous := math.NaN()
+		for i, value := range values {
+			if math.IsNaN(value) {
+				values[i] = previous
+			} else {
+				previous = value
+			}
+		}
+	case "bfill":
+		next := math.NaN()
+		for i := len(values) - 1; i >= 0; i-- {
+			if math.IsNaN(values[i]) {
+				values[i] = next
+			} else {
+				next = values[i]
+			}
+		}
+	case "constant":
+		if math.IsNaN(fill.Value) {
+			return fmt.Errorf("Bad constant fill value for column: %s", fill.Column)
+		}
+		for i, value := range values {
+			if math.IsNaN(value) {
+				values[i] = fill.Value
+			}
+		}
+	case "linear":
+		previous := -1
+		for i, value := range values {
+			if math.IsNaN(value) {
+				continue
+			}
+			if previous >= 0 && i-previous > 1 {
+				step := (value - values[previous]) / float64(i-previous)
+				for j := previous + 1; j < i; j++ {
+					values[j] = values[previous] + step*float64(j-previous)
+				}
+			}
+			previous = i
+		}
+	default:
+		return fmt.Errorf("Unknown fill method: %s", fill.Method)
+	}
+	return nil
+}
+
+// Apply fills the missing values of the dataset
+func (fill Fill) Apply(ds *Dataset) error {
+	columns, err := positions(ds, []string{fill.Column})
+	if err != nil {
+		return err
+	}
+	for _, column := range columns {
+		values := mtx.Col(nil, column, ds.Mtx)
+		if err := fill.Values(values); err != nil {
+			return err
+		}
+		ds.Mtx.SetCol(column, values)
+	}
+	return nil
+}
+
+// NewFill returns a Fill from a string parameter
+//
+// ffill,value
+// constant,*,0
+// ^--------^-^---Method,Column,Value
+func NewFill(arg string) *Fill {
+	split := strings.Split(arg, ",")
+	fill := &Fill{
+		Method: strings.ToLower(split[0]),
+		Column: "*",
+	}
+	if len(split) >= 2 {
+		fill.Column = split[1]
+	}
+	if len(split) >= 3 {
+		value, err := strconv.ParseFloat(split[2], 64)
+		if err != nil {
+			// Rejected when the fill is applied
+			value = math.NaN()
+		}
+		fill.Value = value
+	}
+	return fill
+}
+
+// DropNA removes every row of the dataset
+// which is missing a value in any of the
+// columns, "*" checks every column
+func DropNA(ds *Dataset, columns []string) error {
+	positions, err := positions(ds, columns)
+	if err != nil {
+		return err
+	}
+	rows := make([]int, 0, ds.Len())
+	for i := 0; i < ds.Len(); i++ {
+		missing := false
+		for _, column := range positions {
+			if math.IsNaN(ds.Mtx.At(i, column)) {
+				missing = true
+				break
+			}
+		}
+		if !missing {
+			rows = append(rows, i)
+		}
+	}
+	ds.Mtx = selectRows(ds.Mtx, rows)
+	return nil
+}
+
+// positions returns the position of each named
+// column, "*" matches every column
+func positions(ds *Dataset, columns []string) ([]int, error) {
+	result := make([]int, 0, len(columns))
+	for _, column := range columns {
+		if column == "*" {
+			for i := range ds.Columns {
+				result = append(result, i)
+			}
+			continue
+		}
+		pos := ds.CPos(column)
+		if pos < 0 {
+			return nil, fmt.Errorf("Column not found: %s", column)
+		}
+		result = append(result, pos)
+	}
+	return result, nil
+}
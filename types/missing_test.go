@@ -0,0 +1,51 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestFill(t *testing.T) {
+	nan := math.NaN()
+	for arg, expected := range map[string][]float64{
+		"ffill,v":       {nan, 1, 1, 1, 4, 4},
+		"bfill,v":       {1, 1, 4, 4, 4, nan},
+		"constant,v,-1": {-1, 1, -1, -1, 4, -1},
+		"linear,v":      {nan, 1, 2, 3, 4, nan},
+	} {
+		values := []float64{nan, 1, nan, nan, 4, nan}
+		assert.NoError(t, NewFill(arg).Values(values), arg)
+		for i, value := range values {
+			if math.IsNaN(expected[i]) {
+				assert.True(t, math.IsNaN(value), arg)
+			} else {
+				assert.Equal(t, expected[i], value, arg)
+			}
+		}
+	}
+	assert.Error(t, NewFill("nope,v").Values([]float64{nan}))
+	assert.EqualError(t, NewFill("constant,v,zero").Values([]float64{nan}), "Bad constant fill value for column: v")
+	values := []float64{nan}
+	assert.NoError(t, NewFill("constant,v").Values(values))
+	assert.Equal(t, []float64{0}, values)
+	assert.Equal(t, "constant,*,0.5", NewFill("constant,*,0.5").String())
+}
+
+func TestQueryMissing(t *testing.T) {
+	nan := math.NaN()
+	query := NewQuery([]string{"D0,x,y"}, "", "")
+	query.Fills = []*Fill{NewFill("ffill,x")}
+	query.DropNA = []string{"y"}
+	ds := &Dataset{
+		Columns: []string{"x", "y"},
+		Mtx:     mtx.NewDense(4, 2, []float64{1, 1, nan, 2, 3, nan, nan, 4}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []float64{1, 1, 3}, mtx.Col(nil, 0, ds.Mtx))
+	assert.Equal(t, []float64{1, 2, 4}, mtx.Col(nil, 1, ds.Mtx))
+	assert.NoError(t, DropNA(ds, []string{"*"}))
+	assert.Equal(t, 3, ds.Len())
+	assert.Error(t, DropNA(ds, []string{"z"}))
+}
@@ -0,0 +1,139 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Order sorts the rows of a query result by
+// a column. NaN values are always placed
+// last regardless of direction.
+type Order struct {
+	Column string
+	Desc   bool // Sort in descending order
+}
+
+func (order Order) String() string {
+	if order.Desc {
+		return "-" + order.Column
+	}
+	return order.Column
+}
+
+// NewOrder returns an Order for each column in
+// a comma separated list. Columns prefixed with
+// "-" are sorted in descending order.
+//
+// -value,time
+func NewOrder(arg string) []Order {
+	orders := make([]Order, 0)
+	for _, column := range strings.Split(arg, ",") {
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		order := Order{Column: column}
+		switch column[0] {
+		case '-':
+			order.Column, order.Desc = column[1:], true
+		case '+':
+			order.Column = column[1:]
+		}
+		orders = append(orders, order)
+	}
+	return orders
+}
+
+// rowSorter implements sort.Interface over
+// the rows of a matrix
+type rowSorter struct {
+	rows    []int
+	mx      *mtx.Dense
+	columns []int
+	desc    []bool
+}
+
+func (s rowSorter) Len() int      { return len(s.rows) }
+func (s rowSorter) Swap(i, j int) { s.rows[i], s.rows[j] = s.rows[j], s.rows[i] }
+func (s rowSorter) Less(i, j int) bool {
+	for n, column := range s.columns {
+		a, b := s.mx.At(s.rows[i], column), s.mx.At(s.rows[j], column)
+		switch {
+		case math.IsNaN(a) && math.IsNaN(b):
+			continue
+		case math.IsNaN(a):
+			return false
+		case math.IsNaN(b):
+			return true
+		case a == b:
+			continue
+		case s.desc[n]:
+			return a > b
+		default:
+			return a < b
+		}
+	}
+	return false
+}
+
+// Sort orders the rows of the dataset by each
+// column in orders. Rows which are equal keep
+// their original order.
+func Sort(ds *Dataset, orders []Order) error {
+	sorter := rowSorter{
+		rows:    make([]int, ds.Len()),
+		mx:      ds.Mtx,
+		columns: make([]int, len(orders)),
+		desc:    make([]bool, len(orders)),
+	}
+	for i, order := range orders {
+		pos := ds.CPos(order.Column)
+		if pos < 0 {
+			return fmt.Errorf("Sort column not found: %s", order.Column)
+		}
+		sorter.columns[i] = pos
+		sorter.desc[i] = order.Desc
+	}
+	for i := range sorter.rows {
+		sorter.rows[i] = i
+	}
+	sort.Stable(sorter)
+	ds.Mtx = selectRows(ds.Mtx, sorter.rows)
+	return nil
+}
+
+// Slice limits the dataset to at most limit
+// rows starting at offset. A limit of zero
+// returns all remaining rows.
+func Slice(ds *Dataset, offset, limit int) {
+	r := ds.Len()
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > r {
+		offset = r
+	}
+	end := r
+	if limit > 0 && offset+limit < r {
+		end = offset + limit
+	}
+	rows := make([]int, 0, end-offset)
+	for i := offset; i < end; i++ {
+		rows = append(rows, i)
+	}
+	ds.Mtx = selectRows(ds.Mtx, rows)
+}
+
+// selectRows returns a new matrix containing
+// the rows of mx in the given order
+func selectRows(mx *mtx.Dense, rows []int) *mtx.Dense {
+	_, c := mx.Dims()
+	out := mtx.NewDense(len(rows), c, nil)
+	for i, row := range rows {
+		out.SetRow(i, mx.RawRowView(row))
+	}
+	return out
+}
@@ -0,0 +1,225 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PCA projects the rows of a dataset onto the directions
+// of greatest variance of its columns. Columns are centered
+// on their mean and when Scale is set divided by their
+// standard deviation.
+type PCA struct {
+	Components int      // Number of components, default all
+	Columns    []string // Columns to project, default all
+	Scale      bool
+}
+
+func (pca PCA) String() string {
+	args := append([]string{strconv.Itoa(pca.Components)}, pca.Columns...)
+	if pca.Scale {
+		args = append(args, "scale")
+	}
+	return strings.Join(args, ",")
+}
+
+// PrincipalComponents is the result of PCA
+type PrincipalComponents struct {
+	Variance []float64   // Variance explained by each component
+	Ratio    []float64   // Proportion of the total variance explained
+	Loadings [][]float64 // Weight of each column in each component
+	Scores   *mtx.Dense  `json:"-"` // Rows projected onto each component
+}
+
+// Names returns the name of each component
+func (pc PrincipalComponents) Names() []string {
+	names := make([]string, len(pc.Variance))
+	for i := range names {
+		names[i] = fmt.Sprintf("PC%d", i+1)
+	}
+	return names
+}
+
+// eigenSym returns the eigenvalues of the symmetric matrix a
+// in descending order with the eigenvectors as the columns
+// of a matrix using cyclic Jacobi rotations
+func eigenSym(a *mtx.Dense) ([]float64, *mtx.Dense) {
+	n, _ := a.Dims()
+	a = mtx.DenseCopyOf(a)
+	v := mtx.NewDense(n, n, nil)
+	for i := 0; i < n; i++ {
+		v.Set(i, i, 1)
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		var off float64
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				off += a.At(p, q) * a.At(p, q)
+			}
+		}
+		if off < 1e-24 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if a.At(p, q) == 0 {
+					continue
+				}
+				theta := (a.At(q, q) - a.At(p, p)) / (2 * a.At(p, q))
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					kp, kq := a.At(k, p), a.At(k, q)
+					a.Set(k, p, c*kp-s*kq)
+					a.Set(k, q, s*kp+c*kq)
+				}
+				for k := 0; k < n; k++ {
+					pk, qk := a.At(p, k), a.At(q, k)
+					a.Set(p, k, c*pk-s*qk)
+					a.Set(q, k, s*pk+c*qk)
+				}
+				for k := 0; k < n; k++ {
+					kp, kq := v.At(k, p), v.At(k, q)
+					v.Set(k, p, c*kp-s*kq)
+					v.Set(k, q, s*kp+c*kq)
+				}
+			}
+		}
+	}
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return a.At(order[i], order[i]) > a.At(order[j], order[j]) })
+	values := make([]float64, n)
+	vectors := mtx.NewDense(n, n, nil)
+	for i, j := range order {
+		values[i] = a.At(j, j)
+		// Eigenvectors are unique up to their sign, make
+		// the largest weight positive to be deterministic
+		vector, largest := mtx.Col(nil, j, v), 0
+		for k := range vector {
+			if math.Abs(vector[k]) > math.Abs(vector[largest]) {
+				largest = k
+			}
+		}
+		if vector[largest] < 0 {
+			for k := range vector {
+				vector[k] = -vector[k]
+			}
+		}
+		vectors.SetCol(i, vector)
+	}
+	return values, vectors
+}
+
+// Fit returns the principal components of the
+// columns of mx ignoring rows with missing values,
+// the scores of those rows are NaN
+func (pca PCA) Fit(mx *mtx.Dense) (*PrincipalComponents, error) {
+	r, c := mx.Dims()
+	rows := complete(mx)
+	if c < 1 || len(rows) < 2 {
+		return nil, fmt.Errorf("PCA requires at least two rows without missing values")
+	}
+	k := pca.Components
+	if k == 0 {
+		k = c
+	}
+	if k < 0 || k > c {
+		return nil, fmt.Errorf("Bad number of components %d for %d columns", k, c)
+	}
+	means, scales := make([]float64, c), make([]float64, c)
+	for j := 0; j < c; j++ {
+		var sum, squares float64
+		for _, row := range rows {
+			sum += mx.At(row, j)
+		}
+		means[j] = sum / float64(len(rows))
+		for _, row := range rows {
+			squares += math.Pow(mx.At(row, j)-means[j], 2)
+		}
+		scales[j] = 1
+		if pca.Scale {
+			if scales[j] = math.Sqrt(squares / float64(len(rows)-1)); scales[j] == 0 {
+				return nil, fmt.Errorf("Cannot scale a column without variance")
+			}
+		}
+	}
+	centered := mtx.NewDense(r, c, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			centered.Set(i, j, (mx.At(i, j)-means[j])/scales[j])
+		}
+	}
+	observed := selectRows(centered, rows)
+	covariance := mtx.NewDense(c, c, nil)
+	covariance.Mul(observed.T(), observed)
+	covariance.Scale(1/float64(len(rows)-1), covariance)
+	values, vectors := eigenSym(covariance)
+	var total float64
+	for _, value := range values {
+		total += value
+	}
+	pc := &PrincipalComponents{
+		Variance: values[:k],
+		Ratio:    make([]float64, k),
+		Loadings: make([][]float64, k),
+		Scores:   mtx.NewDense(r, k, nil),
+	}
+	for i := 0; i < k; i++ {
+		if total > 0 {
+			pc.Ratio[i] = values[i] / total
+		}
+		pc.Loadings[i] = mtx.Col(nil, i, vectors)
+	}
+	pc.Scores.Mul(centered, vectors.View(0, 0, c, k))
+	return pc, nil
+}
+
+// Apply replaces the values of the dataset with
+// the score of each row on each component
+func (pca PCA) Apply(ds *Dataset) error {
+	mx, err := selection(ds, pca.Columns)
+	if err != nil {
+		return err
+	}
+	pc, err := pca.Fit(mx)
+	if err != nil {
+		return err
+	}
+	ds.Columns = pc.Names()
+	ds.Mtx = pc.Scores
+	ds.Meta = nil
+	return nil
+}
+
+// NewPCA returns PCA from a string parameter,
+// a final scale argument standardises the columns
+//
+// 2,x,y,z,scale
+// ^-^-------Components,Columns...
+func NewPCA(arg string) *PCA {
+	split := strings.Split(arg, ",")
+	pca := &PCA{}
+	if last := strings.ToLower(split[len(split)-1]); last == "scale" {
+		pca.Scale = true
+		split = split[:len(split)-1]
+	}
+	if len(split) >= 1 {
+		components, _ := strconv.ParseInt(split[0], 0, 64)
+		pca.Components = int(components)
+	}
+	if len(split) >= 2 {
+		pca.Columns = split[1:]
+	}
+	return pca
+}
@@ -0,0 +1,53 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestEigenSym(t *testing.T) {
+	values, vectors := eigenSym(mtx.NewDense(2, 2, []float64{2, 1, 1, 2}))
+	assert.InDeltaSlice(t, []float64{3, 1}, values, 1e-12)
+	assert.InDeltaSlice(t, []float64{math.Sqrt(0.5), math.Sqrt(0.5)}, mtx.Col(nil, 0, vectors), 1e-12)
+	assert.InDeltaSlice(t, []float64{math.Sqrt(0.5), -math.Sqrt(0.5)}, mtx.Col(nil, 1, vectors), 1e-12)
+}
+
+func TestPCA(t *testing.T) {
+	mx := mtx.NewDense(5, 2, []float64{
+		1, 2,
+		2, 4,
+		math.NaN(), 1,
+		3, 6,
+		4, 8,
+	})
+	pc, err := NewPCA("").Fit(mx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"PC1", "PC2"}, pc.Names())
+	assert.InDeltaSlice(t, []float64{25 / 3.0, 0}, pc.Variance, 1e-9)
+	assert.InDeltaSlice(t, []float64{1, 0}, pc.Ratio, 1e-9)
+	assert.InDeltaSlice(t, []float64{1 / math.Sqrt(5), 2 / math.Sqrt(5)}, pc.Loadings[0], 1e-9)
+	assert.InDelta(t, -1.5*math.Sqrt(5), pc.Scores.At(0, 0), 1e-9)
+	assert.True(t, math.IsNaN(pc.Scores.At(2, 0)))
+	pc, err = NewPCA("1,scale").Fit(mx)
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{2}, pc.Variance, 1e-9)
+	assert.InDeltaSlice(t, []float64{math.Sqrt(0.5), math.Sqrt(0.5)}, pc.Loadings[0], 1e-9)
+	_, err = NewPCA("3").Fit(mx)
+	assert.Error(t, err)
+}
+
+func TestQueryPCA(t *testing.T) {
+	query := NewQuery([]string{"D0,time,x,y"}, "", "")
+	query.PCA = NewPCA("1,x,y,scale")
+	ds := &Dataset{
+		Columns: []string{"time", "x", "y"},
+		Mtx:     mtx.NewDense(3, 3, []float64{1, 1, 3, 2, 2, 2, 3, 3, 1}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"PC1"}, ds.Columns)
+	r, c := ds.Mtx.Dims()
+	assert.Equal(t, 3, r)
+	assert.Equal(t, 1, c)
+}
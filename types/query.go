@@ -1,8 +1,10 @@
 package types
 
 import (
-	mtx "github.com/gonum/matrix/mat64"
+	"fmt"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -17,15 +19,55 @@ import (
 // form as URL encoding
 //
 // Text Specification:
-// d=DS1,x,y&d=DS2,z,fuu&grouping=Duration,0,1m&fn=avg
+// d=DS1,x,y&d=DS2,z,fuu&grouping=Duration,0,1m&fn=avg&fill=ffill,x&dropna=y&resample=0,1m,linear&expr=speed=x/y&window=avg,y,10&transform=diff,y&anomaly=zscore,y&decompose=stl,y,12&forecast=holt,y,10&kmeans=3,y,z&corr=pearson&sort=-y,x&limit=10&offset=20
 //
 type Query struct {
 	Datasets []struct {
 		Name    string   // Name of the dataset
 		Columns []string // Columns within the dataset to query
 	}
-	Function *Function
-	Grouping *Grouping
+	Function        *Function
+	Grouping        *Grouping
+	Fills           []*Fill          // Missing values to fill
+	DropNA          []string         // Columns where rows with missing values are dropped
+	Resample        *Resample        // Interpolate rows onto a regular time grid
+	Derived         []Derived        // Columns computed from expressions
+	Windows         []*Window        // Rolling window columns
+	Transforms      []*Transform     // Columns transformed from previous rows
+	Anomalies       []*Anomaly       // Columns flagging outliers
+	Decomposition   *Decomposition   // Trend, seasonal and residual columns
+	Forecast        *Forecast        // Append forecast rows to the result
+	KMeans          *KMeans          // Add the cluster of each row
+	PCA             *PCA             // Replace the result with its principal components
+	Spectrum        *Spectrum        // Replace the result with its power spectrum
+	Autocorrelation *Autocorrelation // Replace the result with its autocorrelation
+	Histogram       *Histogram       // Replace the result with the bins of a column
+	Correlation     *Correlation     // Replace the result with its correlation matrix
+	Order           []Order          // Columns to sort the result by
+	Limit           int              // Maximum number of rows, 0 for all
+	Offset          int              // Number of rows to skip
+}
+
+// Derived is a column computed for each
+// row of a query result from an Expr
+type Derived struct {
+	Name string
+	Expr string
+}
+
+func (derived Derived) String() string {
+	return fmt.Sprintf("%s=%s", derived.Name, derived.Expr)
+}
+
+// NewDerived returns a Derived column from
+// a string in the form NAME=EXPR
+func NewDerived(arg string) Derived {
+	split := strings.SplitN(arg, "=", 2)
+	derived := Derived{Name: strings.TrimSpace(split[0])}
+	if len(split) > 1 {
+		derived.Expr = split[1]
+	}
+	return derived
 }
 
 // Len returns the length of the Query
@@ -60,15 +102,214 @@ func (query Query) String() string {
 		}
 		values.Add("q", strings.TrimRight(strings.Join(args, ","), ","))
 	}
+	for _, fill := range query.Fills {
+		values.Add("fill", fill.String())
+	}
+	if len(query.DropNA) > 0 {
+		values.Add("dropna", strings.Join(query.DropNA, ","))
+	}
+	if query.Resample != nil {
+		values.Add("resample", query.Resample.String())
+	}
+	for _, derived := range query.Derived {
+		values.Add("expr", derived.String())
+	}
+	for _, window := range query.Windows {
+		values.Add("window", window.String())
+	}
+	for _, transform := range query.Transforms {
+		values.Add("transform", transform.String())
+	}
+	for _, anomaly := range query.Anomalies {
+		values.Add("anomaly", anomaly.String())
+	}
+	if query.Decomposition != nil {
+		values.Add("decompose", query.Decomposition.String())
+	}
+	if query.Forecast != nil {
+		values.Add("forecast", query.Forecast.String())
+	}
+	if query.KMeans != nil {
+		values.Add("kmeans", query.KMeans.String())
+	}
+	if query.PCA != nil {
+		values.Add("pca", query.PCA.String())
+	}
+	if query.Spectrum != nil {
+		values.Add("spectrum", query.Spectrum.String())
+	}
+	if query.Autocorrelation != nil {
+		values.Add("acf", query.Autocorrelation.String())
+	}
+	if query.Histogram != nil {
+		values.Add("histogram", query.Histogram.String())
+	}
+	if query.Correlation != nil {
+		values.Add("corr", query.Correlation.String())
+	}
+	if len(query.Order) > 0 {
+		orders := make([]string, len(query.Order))
+		for i, order := range query.Order {
+			orders[i] = order.String()
+		}
+		values.Add("sort", strings.Join(orders, ","))
+	}
+	if query.Limit > 0 {
+		values.Add("limit", strconv.Itoa(query.Limit))
+	}
+	if query.Offset > 0 {
+		values.Add("offset", strconv.Itoa(query.Offset))
+	}
 	return values.Encode()
 }
 
-// Apply returns a new modified matrix based on the query
-func (query Query) Apply(mx *mtx.Dense) *mtx.Dense {
+// replacements returns the parameter of each stage
+// which replaces the values of the result
+func (query Query) replacements() []string {
+	replaced := make([]string, 0)
+	for name, set := range map[string]bool{
+		"pca":       query.PCA != nil,
+		"spectrum":  query.Spectrum != nil,
+		"acf":       query.Autocorrelation != nil,
+		"histogram": query.Histogram != nil,
+		"corr":      query.Correlation != nil,
+	} {
+		if set {
+			replaced = append(replaced, name)
+		}
+	}
+	sort.Strings(replaced)
+	return replaced
+}
+
+// Apply modifies the dataset based on the query
+// with each stage in order:
+//
+//	fill and dropna   missing values
+//	resample          rows onto a regular time grid
+//	expr, window,
+//	transform         columns, aggregated when grouped
+//	grouping          rows by the function
+//	anomaly           flags of the grouped values
+//	decompose         trend, seasonal and residual columns
+//	forecast          rows appended to the result
+//	kmeans            cluster column
+//	pca, spectrum,
+//	acf, histogram,
+//	corr              replace the result, at most one
+//	sort, offset
+//	and limit         rows of the result
+func (query Query) Apply(ds *Dataset) error {
+	if replaced := query.replacements(); len(replaced) > 1 {
+		return fmt.Errorf("Only one of pca, spectrum, acf, histogram or corr can be queried, got: %s", strings.Join(replaced, ", "))
+	}
+	for _, fill := range query.Fills {
+		if err := fill.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if len(query.DropNA) > 0 {
+		if err := DropNA(ds, query.DropNA); err != nil {
+			return err
+		}
+	}
+	if query.Resample != nil {
+		resample := *query.Resample
+		resample.Precision = ds.ColumnMeta(resample.Index).Precision
+		if err := resample.Apply(ds); err != nil {
+			return err
+		}
+	}
+	for _, derived := range query.Derived {
+		if derived.Name == "" || derived.Expr == "" {
+			return fmt.Errorf("Bad expression, use NAME=EXPR: %s", derived)
+		}
+		if ds.CPos(derived.Name) >= 0 {
+			return fmt.Errorf("Derived column already exists: %s", derived.Name)
+		}
+		expr, err := ParseExpr(derived.Expr)
+		if err != nil {
+			return err
+		}
+		values, err := expr.Eval(ds)
+		if err != nil {
+			return err
+		}
+		ds.AddColumn(derived.Name, values, Meta{})
+	}
+	for _, w := range query.Windows {
+		window := *w
+		window.Precision = ds.ColumnMeta(window.Index).Precision
+		if err := window.Apply(ds); err != nil {
+			return err
+		}
+	}
+	for _, transform := range query.Transforms {
+		if err := transform.Apply(ds); err != nil {
+			return err
+		}
+	}
 	if query.Grouping != nil {
-		return query.Function.Apply(query.Grouping.Group(mx))
+		grouping := *query.Grouping
+		grouping.Precision = ds.ColumnMeta(grouping.Index).Precision
+		ds.Mtx = query.Function.Apply(grouping.Group(ds.Mtx))
+	}
+	for _, anomaly := range query.Anomalies {
+		if err := anomaly.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Decomposition != nil {
+		if err := query.Decomposition.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Forecast != nil {
+		if err := query.Forecast.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.KMeans != nil {
+		if err := query.KMeans.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.PCA != nil {
+		if err := query.PCA.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Spectrum != nil {
+		spectrum := *query.Spectrum
+		spectrum.Precision = ds.ColumnMeta(spectrum.Index).Precision
+		if err := spectrum.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Autocorrelation != nil {
+		if err := query.Autocorrelation.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Histogram != nil {
+		if err := query.Histogram.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if query.Correlation != nil {
+		if err := query.Correlation.Apply(ds); err != nil {
+			return err
+		}
+	}
+	if len(query.Order) > 0 {
+		if err := Sort(ds, query.Order); err != nil {
+			return err
+		}
+	}
+	if query.Limit > 0 || query.Offset > 0 {
+		Slice(ds, query.Offset, query.Limit)
 	}
-	return mx
+	return nil
 }
 
 // NewQuery constructs a Query from the provided
@@ -100,12 +341,86 @@ func NewQuery(args []string, function, grouping string) *Query {
 	return query
 }
 
+// natural parses a non-negative base 10
+// integer, an empty value is 0
+func natural(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, ErrBadQuery
+	}
+	return n, nil
+}
+
 // NewQueryQS constructs a query from a url.URL
-func NewQueryQS(u *url.URL) *Query {
+// and returns ErrBadQuery if the limit or offset
+// is not a non-negative integer
+func NewQueryQS(u *url.URL) (*Query, error) {
 	var args []string
 	query := u.Query()
 	if q, ok := query["q"]; ok {
 		args = q
 	}
-	return NewQuery(args, query.Get("fn"), query.Get("grouping"))
+	q := NewQuery(args, query.Get("fn"), query.Get("grouping"))
+	for _, fill := range query["fill"] {
+		q.Fills = append(q.Fills, NewFill(fill))
+	}
+	for _, dropna := range query["dropna"] {
+		q.DropNA = append(q.DropNA, strings.Split(dropna, ",")...)
+	}
+	if resample := query.Get("resample"); resample != "" {
+		q.Resample = NewResample(resample)
+	}
+	for _, expr := range query["expr"] {
+		q.Derived = append(q.Derived, NewDerived(expr))
+	}
+	for _, window := range query["window"] {
+		q.Windows = append(q.Windows, NewWindow(window))
+	}
+	for _, transform := range query["transform"] {
+		q.Transforms = append(q.Transforms, NewTransform(transform))
+	}
+	for _, anomaly := range query["anomaly"] {
+		q.Anomalies = append(q.Anomalies, NewAnomaly(anomaly))
+	}
+	if decompose := query.Get("decompose"); decompose != "" {
+		q.Decomposition = NewDecomposition(decompose)
+	}
+	if forecast := query.Get("forecast"); forecast != "" {
+		q.Forecast = NewForecast(forecast)
+	}
+	if kmeans := query.Get("kmeans"); kmeans != "" {
+		q.KMeans = NewKMeans(kmeans)
+	}
+	if pca := query.Get("pca"); pca != "" {
+		q.PCA = NewPCA(pca)
+	}
+	if spectrum := query.Get("spectrum"); spectrum != "" {
+		q.Spectrum = NewSpectrum(spectrum)
+	}
+	if acf := query.Get("acf"); acf != "" {
+		q.Autocorrelation = NewAutocorrelation(acf)
+	}
+	if histogram := query.Get("histogram"); histogram != "" {
+		q.Histogram = NewHistogram(histogram)
+	}
+	if corr := query.Get("corr"); corr != "" {
+		q.Correlation = &Correlation{Method: corr}
+	}
+	for _, order := range query["sort"] {
+		q.Order = append(q.Order, NewOrder(order)...)
+	}
+	limit, err := natural(query.Get("limit"))
+	if err != nil {
+		return nil, err
+	}
+	q.Limit = limit
+	offset, err := natural(query.Get("offset"))
+	if err != nil {
+		return nil, err
+	}
+	q.Offset = offset
+	return q, nil
 }
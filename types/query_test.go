@@ -1,7 +1,9 @@
 package types
 
 import (
+	mtx "github.com/gonum/matrix/mat64"
 	"github.com/stretchr/testify/assert"
+	"math"
 	"net/url"
 	"testing"
 	"time"
@@ -10,7 +12,8 @@ import (
 func TestQuery(t *testing.T) {
 	u, err := url.Parse("http://localhost/?q=D0,x,y,z&q=D1,z&grouping=Duration,0,1m&fn=avg")
 	assert.NoError(t, err)
-	query := NewQueryQS(u)
+	query, err := NewQueryQS(u)
+	assert.NoError(t, err)
 	assert.Equal(t, 2, query.Len())
 	assert.Equal(t, "D0", query.Datasets[0].Name)
 	assert.Equal(t, 3, len(query.Datasets[0].Columns))
@@ -25,3 +28,88 @@ func TestQuery(t *testing.T) {
 	assert.Equal(t, time.Minute, query.Grouping.Max)
 	assert.Equal(t, "fn=avg&grouping=Duration%2C0%2C1m0s&q=D0%2Cx%2Cy%2Cz&q=D1%2Cz", query.String())
 }
+
+func TestQueryOrder(t *testing.T) {
+	u, err := url.Parse("http://localhost/?q=D0,x,y&sort=-y,x&limit=2&offset=1")
+	assert.NoError(t, err)
+	query, err := NewQueryQS(u)
+	assert.NoError(t, err)
+	assert.Equal(t, []Order{{Column: "y", Desc: true}, {Column: "x"}}, query.Order)
+	assert.Equal(t, 2, query.Limit)
+	assert.Equal(t, 1, query.Offset)
+	ds := &Dataset{
+		Columns: []string{"x", "y"},
+		Mtx:     mtx.NewDense(5, 2, []float64{1, 1, 2, math.NaN(), 3, 2, 4, 1, 5, 2}),
+	}
+	query.Limit, query.Offset = 0, 0
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []float64{3, 5, 1, 4, 2}, mtx.Col(nil, 0, ds.Mtx))
+	query.Limit, query.Offset = 2, 1
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []float64{5, 1}, mtx.Col(nil, 0, ds.Mtx))
+	query.Offset = 10
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, 0, ds.Len())
+	query.Order = NewOrder("z")
+	assert.Error(t, query.Apply(ds))
+	u, err = url.Parse("http://localhost/?q=D0,x,y&limit=010&offset=0")
+	assert.NoError(t, err)
+	query, err = NewQueryQS(u)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, query.Limit)
+	for _, params := range []string{"limit=abc", "limit=-1", "offset=-5", "offset=0x10", "limit=1.5"} {
+		u, err = url.Parse("http://localhost/?q=D0,x,y&" + params)
+		assert.NoError(t, err)
+		_, err = NewQueryQS(u)
+		assert.Equal(t, ErrBadQuery, err, params)
+	}
+}
+
+func TestQueryReplacements(t *testing.T) {
+	query := NewQuery([]string{"D0,x,y"}, "", "")
+	query.Correlation = &Correlation{Method: "pearson"}
+	query.Histogram = NewHistogram("width,x,10")
+	ds := &Dataset{Columns: []string{"x", "y"}, Mtx: mtx.NewDense(2, 2, []float64{1, 2, 3, 4})}
+	assert.EqualError(t, query.Apply(ds), "Only one of pca, spectrum, acf, histogram or corr can be queried, got: corr, histogram")
+	query.Histogram = nil
+	assert.NoError(t, query.Apply(ds))
+}
+
+func TestQueryRoundTrip(t *testing.T) {
+	for _, params := range []string{
+		"grouping=Duration,0,1m&fn=avg",
+		"fill=linear,y",
+		"dropna=x,y",
+		"resample=0,2m,linear",
+		"expr=z=x%2By&expr=w=z*2",
+		"window=avg,y,2m,0",
+		"transform=diff,y,1&transform=cumsum,y",
+		"anomaly=iqr,y,1.5,3&anomaly=zscore,y",
+		"decompose=classical,y,4",
+		"forecast=holt,y,2",
+		"kmeans=2,y",
+		"pca=1,x,y,scale",
+		"spectrum=welch,y,8,0",
+		"acf=pacf,y,4",
+		"histogram=edges,y,0,5,10,density",
+		"corr=spearman",
+		"sort=-y,x&limit=2&offset=1",
+	} {
+		u, err := url.Parse("http://localhost/?q=D0,x,y&" + params)
+		assert.NoError(t, err)
+		query, err := NewQueryQS(u)
+		assert.NoError(t, err)
+		parsed, err := NewQueryQS(&url.URL{RawQuery: query.String()})
+		assert.NoError(t, err)
+		assert.Equal(t, query, parsed, params)
+		ds := &Dataset{
+			Columns: []string{"x", "y"},
+			Meta:    []Meta{{Precision: Seconds}, {}},
+			Mtx:     mtx.NewDense(16, 2, nil),
+		}
+		for i, value := range seasonalSeries(16) {
+			ds.Mtx.SetRow(i, []float64{float64(i * 60), value})
+		}
+		assert.NoError(t, query.Apply(ds), params)
+	}
+}
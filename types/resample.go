@@ -0,0 +1,292 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxResampleRows limits the number of rows a
+// resample may produce
+const MaxResampleRows = 1000000
+
+// Resample interpolates the values of a dataset
+// onto evenly spaced times of the time column at
+// Index. The grid starts at the first time value
+// rounded up to Interval unless Start is given and
+// ends at the last time value unless End is given.
+// Missing values are ignored when interpolating and
+// the values of repeated times are averaged.
+//
+// nearest   value of the closest time
+// previous  value of the closest earlier time
+// linear    linear interpolation
+// spline    natural cubic spline interpolation
+//
+// Times outside of the values of a column are NaN
+// except for nearest.
+type Resample struct {
+	Index     int
+	Interval  time.Duration
+	Method    string
+	Start     time.Time
+	End       time.Time
+	Precision Precision // Precision of the time values at Index
+}
+
+func (rs Resample) String() string {
+	str := fmt.Sprintf("%d,%s,%s", rs.Index, rs.Interval, rs.Method)
+	if !rs.Start.IsZero() || !rs.End.IsZero() {
+		str += "," + formatTime(rs.Start) + "," + formatTime(rs.End)
+	}
+	return str
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// Grid returns evenly spaced time values covering
+// the times, which must be in ascending order
+func (rs Resample) Grid(times []float64) ([]float64, error) {
+	if rs.Interval <= 0 {
+		return nil, fmt.Errorf("Bad resample interval: %s", rs.Interval)
+	}
+	if rs.Interval < rs.Precision.Unit() || rs.Interval%rs.Precision.Unit() != 0 {
+		return nil, fmt.Errorf("Resample interval %s cannot be represented with the precision of the time column", rs.Interval)
+	}
+	if len(times) == 0 {
+		return []float64{}, nil
+	}
+	start, end := rs.Start, rs.End
+	if start.IsZero() {
+		first := rs.Precision.Time(times[0])
+		start = first.Truncate(rs.Interval)
+		if start.Before(first) {
+			start = start.Add(rs.Interval)
+		}
+	}
+	if end.IsZero() {
+		end = rs.Precision.Time(times[len(times)-1])
+	}
+	if end.Before(start) {
+		return []float64{}, nil
+	}
+	n := int64(end.Sub(start)/rs.Interval) + 1
+	if n > MaxResampleRows {
+		return nil, fmt.Errorf("Resample would produce %d rows, more than %d", n, MaxResampleRows)
+	}
+	grid := make([]float64, n)
+	for i := range grid {
+		grid[i] = rs.Precision.Value(start.Add(time.Duration(i) * rs.Interval))
+	}
+	return grid, nil
+}
+
+// Apply replaces the rows of the dataset with
+// the resampled values
+func (rs Resample) Apply(ds *Dataset) error {
+	r, c := ds.Mtx.Dims()
+	if rs.Index < 0 || rs.Index >= c {
+		return fmt.Errorf("Resample column out of range: %d", rs.Index)
+	}
+	interpolate, ok := interpolators[rs.Method]
+	if !ok {
+		return fmt.Errorf("Unknown resample method: %s", rs.Method)
+	}
+	// Rows missing a time value are ignored
+	rows := make([]int, 0, r)
+	for i := 0; i < r; i++ {
+		if t := ds.Mtx.At(i, rs.Index); !math.IsNaN(t) {
+			if len(rows) > 0 && t < ds.Mtx.At(rows[len(rows)-1], rs.Index) {
+				return fmt.Errorf("Resample requires ascending time values")
+			}
+			rows = append(rows, i)
+		}
+	}
+	times := make([]float64, len(rows))
+	for i, row := range rows {
+		times[i] = ds.Mtx.At(row, rs.Index)
+	}
+	grid, err := rs.Grid(times)
+	if err != nil {
+		return err
+	}
+	mx := mtx.NewDense(len(grid), c, nil)
+	for j := 0; j < c; j++ {
+		if j == rs.Index {
+			mx.SetCol(j, grid)
+			continue
+		}
+		xs, ys := make([]float64, 0, len(rows)), make([]float64, 0, len(rows))
+		for i, row := range rows {
+			if y := ds.Mtx.At(row, j); !math.IsNaN(y) {
+				xs, ys = append(xs, times[i]), append(ys, y)
+			}
+		}
+		if len(grid) > 0 {
+			xs, ys = collapse(xs, ys)
+			mx.SetCol(j, interpolate(xs, ys, grid))
+		}
+	}
+	ds.Mtx = mx
+	return nil
+}
+
+// collapse averages the values of repeated times
+// so interpolators only see distinct ascending times
+func collapse(xs, ys []float64) ([]float64, []float64) {
+	outX, outY := make([]float64, 0, len(xs)), make([]float64, 0, len(ys))
+	count := 0.0
+	for i, x := range xs {
+		if last := len(outX) - 1; last >= 0 && outX[last] == x {
+			count++
+			outY[last] += (ys[i] - outY[last]) / count
+			continue
+		}
+		outX, outY, count = append(outX, x), append(outY, ys[i]), 1
+	}
+	return outX, outY
+}
+
+type interpolator func(xs, ys, grid []float64) []float64
+
+var interpolators = map[string]interpolator{
+	"nearest":  nearest,
+	"previous": previous,
+	"linear":   linear,
+	"spline":   spline,
+}
+
+func nans(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+func nearest(xs, ys, grid []float64) []float64 {
+	values := nans(len(grid))
+	if len(xs) == 0 {
+		return values
+	}
+	for i, x := range grid {
+		k := sort.SearchFloat64s(xs, x)
+		switch {
+		case k == 0:
+			values[i] = ys[0]
+		case k == len(xs):
+			values[i] = ys[k-1]
+		case xs[k]-x < x-xs[k-1]:
+			values[i] = ys[k]
+		default:
+			values[i] = ys[k-1]
+		}
+	}
+	return values
+}
+
+func previous(xs, ys, grid []float64) []float64 {
+	values := nans(len(grid))
+	for i, x := range grid {
+		// Index of the first value after x
+		k := sort.Search(len(xs), func(n int) bool { return xs[n] > x })
+		if k > 0 {
+			values[i] = ys[k-1]
+		}
+	}
+	return values
+}
+
+func linear(xs, ys, grid []float64) []float64 {
+	values := nans(len(grid))
+	for i, x := range grid {
+		k := sort.SearchFloat64s(xs, x)
+		switch {
+		case k == len(xs):
+		case xs[k] == x:
+			values[i] = ys[k]
+		case k > 0:
+			values[i] = ys[k-1] + (ys[k]-ys[k-1])*(x-xs[k-1])/(xs[k]-xs[k-1])
+		}
+	}
+	return values
+}
+
+// spline interpolates with a natural cubic spline
+// and falls back to linear with fewer than three
+// values
+func spline(xs, ys, grid []float64) []float64 {
+	n := len(xs)
+	if n < 3 {
+		return linear(xs, ys, grid)
+	}
+	// Solve the tridiagonal system for the second
+	// derivatives m with m[0] = m[n-1] = 0
+	m := make([]float64, n)
+	c := make([]float64, n)
+	d := make([]float64, n)
+	for i := 1; i < n-1; i++ {
+		h0, h1 := xs[i]-xs[i-1], xs[i+1]-xs[i]
+		a, b := h0, 2*(h0+h1)
+		rhs := 6 * ((ys[i+1]-ys[i])/h1 - (ys[i]-ys[i-1])/h0)
+		b -= a * c[i-1]
+		c[i] = h1 / b
+		d[i] = (rhs - a*d[i-1]) / b
+	}
+	for i := n - 2; i > 0; i-- {
+		m[i] = d[i] - c[i]*m[i+1]
+	}
+	values := nans(len(grid))
+	for i, x := range grid {
+		k := sort.SearchFloat64s(xs, x)
+		switch {
+		case k == n:
+		case xs[k] == x:
+			values[i] = ys[k]
+		case k > 0:
+			h := xs[k] - xs[k-1]
+			a, b := (xs[k]-x)/h, (x-xs[k-1])/h
+			values[i] = a*ys[k-1] + b*ys[k] + ((a*a*a-a)*m[k-1]+(b*b*b-b)*m[k])*h*h/6
+		}
+	}
+	return values
+}
+
+// NewResample returns a Resample from a string
+// parameter. START and END are optional RFC3339
+// times.
+//
+// 0,1m,linear
+// 0,1m,nearest,2017-01-01T00:00:00Z,2017-01-02T00:00:00Z
+// ^-^--^-------^--------------------^---Index,Interval,Method,Start,End
+func NewResample(arg string) *Resample {
+	split := strings.Split(arg, ",")
+	resample := &Resample{Method: "linear"}
+	if len(split) >= 1 {
+		index, _ := strconv.ParseInt(split[0], 0, 64)
+		resample.Index = int(index)
+	}
+	if len(split) >= 2 {
+		interval, _ := time.ParseDuration(split[1])
+		resample.Interval = interval
+	}
+	if len(split) >= 3 && split[2] != "" {
+		resample.Method = strings.ToLower(split[2])
+	}
+	if len(split) >= 4 {
+		resample.Start, _ = time.Parse(time.RFC3339Nano, split[3])
+	}
+	if len(split) >= 5 {
+		resample.End, _ = time.Parse(time.RFC3339Nano, split[4])
+	}
+	return resample
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	resample := NewResample("0,10s,linear")
+	assert.Equal(t, 10*time.Second, resample.Interval)
+	assert.Equal(t, "0,10s,linear", resample.String())
+	times := []float64{5, 12, 31, 40}
+	grid, err := resample.Grid(times)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{10, 20, 30, 40}, grid)
+
+	xs, ys := []float64{0, 10, 20, 30}, []float64{0, 10, 0, 10}
+	grid = []float64{-5, 0, 4, 6, 15, 30, 35}
+	assert.Equal(t, []float64{0, 0, 0, 10, 10, 10, 10}, nearest(xs, ys, grid))
+	values := previous(xs, ys, grid)
+	assert.True(t, math.IsNaN(values[0]))
+	assert.Equal(t, []float64{0, 0, 0, 10, 10, 10}, values[1:])
+	values = linear(xs, ys, grid)
+	assert.True(t, math.IsNaN(values[0]))
+	assert.True(t, math.IsNaN(values[6]))
+	assert.Equal(t, []float64{0, 4, 6, 5, 10}, values[1:6])
+	// A spline through points on a line is the line
+	values = spline([]float64{0, 1, 2, 3}, []float64{1, 3, 5, 7}, []float64{0.5, 1.5, 2.25})
+	for i, expected := range []float64{2, 4, 5.5} {
+		assert.InDelta(t, expected, values[i], 1e-9)
+	}
+	// The values are symmetric about (15, 5)
+	values = spline(xs, ys, []float64{5, 10, 15, 25})
+	assert.Equal(t, 10.0, values[1])
+	assert.InDelta(t, 5, values[2], 1e-9)
+	assert.InDelta(t, 10, values[0]+values[3], 1e-9)
+
+	_, err = NewResample("0,1ms,linear").Grid(times)
+	assert.Error(t, err)
+	_, err = NewResample("0,1ns,linear").Grid([]float64{0, 1e9})
+	assert.Error(t, err)
+}
+
+func TestQueryResample(t *testing.T) {
+	query := NewQuery([]string{"D0,time,value"}, "", "")
+	query.Resample = NewResample("0,1m,previous")
+	nan := math.NaN()
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{Precision: Milliseconds}},
+		Mtx:     mtx.NewDense(4, 2, []float64{0, 1, 50000, nan, 70000, 2, 185000, 3}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []float64{0, 60000, 120000, 180000}, mtx.Col(nil, 0, ds.Mtx))
+	assert.Equal(t, []float64{1, 1, 2, 2}, mtx.Col(nil, 1, ds.Mtx))
+	ds.Mtx = mtx.NewDense(2, 2, []float64{60000, 1, 0, 2})
+	assert.Error(t, query.Apply(ds))
+	query.Resample = NewResample("0,1m,nope")
+	assert.Error(t, query.Apply(ds))
+}
+
+func TestResampleRepeatedTimes(t *testing.T) {
+	xs, ys := collapse([]float64{0, 1, 1, 1, 2}, []float64{1, 2, 4, 6, 3})
+	assert.Equal(t, []float64{0, 1, 2}, xs)
+	assert.Equal(t, []float64{1, 4, 3}, ys)
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{Precision: Seconds}},
+		Mtx:     mtx.NewDense(5, 2, []float64{0, 0, 1, 1, 1, 3, 2, 4, 3, 9}),
+	}
+	assert.NoError(t, NewResample("0,1s,spline").Apply(ds))
+	for i, value := range mtx.Col(nil, 1, ds.Mtx) {
+		assert.False(t, math.IsNaN(value), "row %d", i)
+	}
+	assert.Equal(t, []float64{0, 2, 4, 9}, mtx.Col(nil, 1, ds.Mtx))
+}
@@ -0,0 +1,237 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// Spectrum estimates the power spectral density of
+// a column sampled at a uniform interval of the time
+// column at Index, or once per row if Index is negative.
+// Frequencies are in cycles per second when the time
+// column has a Precision.
+//
+// periodogram: the squared magnitude of the Fourier
+// transform of all values.
+// welch: the average periodogram of Hann windowed
+// segments of Segment rows overlapping by half.
+type Spectrum struct {
+	Method    string
+	Column    string
+	Segment   int
+	Index     int
+	Precision Precision
+}
+
+func (sp Spectrum) String() string {
+	return fmt.Sprintf("%s,%s,%d,%d", sp.Method, sp.Column, sp.Segment, sp.Index)
+}
+
+// fft returns the discrete Fourier transform of values
+// using radix 2 for powers of two and Bluestein's chirp-z
+// algorithm for any other length
+func fft(values []complex128) []complex128 {
+	n := len(values)
+	if n&(n-1) == 0 {
+		return radix2(values)
+	}
+	return bluestein(values)
+}
+
+// radix2 returns the discrete Fourier transform of
+// values with a length that is a power of two
+func radix2(values []complex128) []complex128 {
+	n := len(values)
+	if n <= 1 {
+		return append([]complex128(nil), values...)
+	}
+	even, odd := make([]complex128, n/2), make([]complex128, n/2)
+	for i := 0; i < n/2; i++ {
+		even[i], odd[i] = values[2*i], values[2*i+1]
+	}
+	even, odd = radix2(even), radix2(odd)
+	result := make([]complex128, n)
+	for k := 0; k < n/2; k++ {
+		t := cmplx.Exp(complex(0, -2*math.Pi*float64(k)/float64(n))) * odd[k]
+		result[k] = even[k] + t
+		result[k+n/2] = even[k] - t
+	}
+	return result
+}
+
+// bluestein returns the discrete Fourier transform of
+// values of any length as a convolution with a chirp
+// computed by power of two transforms
+func bluestein(values []complex128) []complex128 {
+	n := len(values)
+	m := 1
+	for m < 2*n-1 {
+		m *= 2
+	}
+	chirp := make([]complex128, n)
+	for k := range chirp {
+		// k² modulo 2n keeps the angle precise for large k
+		chirp[k] = cmplx.Exp(complex(0, -math.Pi*float64((k*k)%(2*n))/float64(n)))
+	}
+	a, b := make([]complex128, m), make([]complex128, m)
+	for k, value := range values {
+		a[k] = value * chirp[k]
+	}
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = b[k]
+	}
+	a, b = radix2(a), radix2(b)
+	// Inverse transform of the product by conjugation
+	for i := range a {
+		a[i] = cmplx.Conj(a[i] * b[i])
+	}
+	a = radix2(a)
+	result := make([]complex128, n)
+	for k := range result {
+		result[k] = chirp[k] * cmplx.Conj(a[k]) / complex(float64(m), 0)
+	}
+	return result
+}
+
+// Interval returns the sampling interval of times
+func (sp Spectrum) Interval(times []float64) (float64, error) {
+	if len(times) < 2 {
+		return 0, fmt.Errorf("Spectrum requires at least two values")
+	}
+	interval := times[1] - times[0]
+	for i := 1; i < len(times); i++ {
+		if d := times[i] - times[i-1]; !(d > 0) || math.Abs(d-interval) > 1e-6*interval {
+			return 0, fmt.Errorf("Spectrum requires uniformly sampled times, resample first")
+		}
+	}
+	if sp.Precision != "" {
+		interval *= sp.Precision.Unit().Seconds()
+	}
+	return interval, nil
+}
+
+// Values returns the frequencies above zero and the
+// one sided power at each for values sampled every
+// interval
+func (sp Spectrum) Values(values []float64, interval float64) (frequencies, power []float64, err error) {
+	n := len(values)
+	if n < 2 {
+		return nil, nil, fmt.Errorf("Spectrum requires at least two values")
+	}
+	for _, value := range values {
+		if math.IsNaN(value) {
+			return nil, nil, fmt.Errorf("Spectrum requires values without missing data")
+		}
+	}
+	size := n
+	window := make([]float64, n)
+	switch sp.Method {
+	case "periodogram":
+		for i := range window {
+			window[i] = 1
+		}
+	case "welch":
+		if sp.Segment < 2 {
+			return nil, nil, fmt.Errorf("Bad spectrum segment: %d", sp.Segment)
+		}
+		if sp.Segment < n {
+			size = sp.Segment
+		}
+		window = window[:size]
+		for i := range window {
+			window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+		}
+	default:
+		return nil, nil, fmt.Errorf("Unknown spectrum method: %s", sp.Method)
+	}
+	var scale float64
+	for _, w := range window {
+		scale += w * w
+	}
+	power = make([]float64, size/2)
+	segments := 0
+	for start := 0; start+size <= n; start += (size + 1) / 2 {
+		segment := values[start : start+size]
+		m := mean(segment)
+		transformed := make([]complex128, size)
+		for i, value := range segment {
+			transformed[i] = complex((value-m)*window[i], 0)
+		}
+		transformed = fft(transformed)
+		for k := range power {
+			power[k] += math.Pow(cmplx.Abs(transformed[k+1]), 2)
+		}
+		segments++
+	}
+	frequencies = make([]float64, len(power))
+	for k := range power {
+		frequencies[k] = float64(k+1) / (float64(size) * interval)
+		power[k] *= interval / (scale * float64(segments))
+		if size%2 == 1 || k+1 < size/2 { // Fold negative frequencies, except Nyquist
+			power[k] *= 2
+		}
+	}
+	return frequencies, power, nil
+}
+
+// Apply replaces the values of the dataset
+// with the frequency and power columns
+func (sp Spectrum) Apply(ds *Dataset) error {
+	column := ds.CPos(sp.Column)
+	if column < 0 {
+		return fmt.Errorf("Spectrum column not found: %s", sp.Column)
+	}
+	interval := 1.0
+	if sp.Index >= 0 {
+		_, c := ds.Mtx.Dims()
+		if sp.Index >= c {
+			return fmt.Errorf("Spectrum index out of range: %d", sp.Index)
+		}
+		var err error
+		if interval, err = sp.Interval(mtx.Col(nil, sp.Index, ds.Mtx)); err != nil {
+			return err
+		}
+	}
+	frequencies, power, err := sp.Values(mtx.Col(nil, column, ds.Mtx), interval)
+	if err != nil {
+		return err
+	}
+	mx := mtx.NewDense(len(power), 2, nil)
+	mx.SetCol(0, frequencies)
+	mx.SetCol(1, power)
+	ds.Columns = []string{"frequency", "power"}
+	ds.Mtx = mx
+	ds.Meta = nil
+	return nil
+}
+
+// NewSpectrum returns a Spectrum from a string parameter,
+// Segment defaults to 256 rows and Index to 0
+//
+// welch,value,128,0
+// ^-----^-----^---^---Method,Column,Segment,Index
+func NewSpectrum(arg string) *Spectrum {
+	split := strings.Split(arg, ",")
+	spectrum := &Spectrum{
+		Method:  strings.ToLower(split[0]),
+		Segment: 256,
+	}
+	if len(split) >= 2 {
+		spectrum.Column = split[1]
+	}
+	if len(split) >= 3 && split[2] != "" {
+		segment, _ := strconv.ParseInt(split[2], 0, 64)
+		spectrum.Segment = int(segment)
+	}
+	if len(split) >= 4 {
+		index, _ := strconv.ParseInt(split[3], 0, 64)
+		spectrum.Index = int(index)
+	}
+	return spectrum
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+)
+
+func sine(n, period int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.Sin(2 * math.Pi * float64(i) / float64(period))
+	}
+	return values
+}
+
+func peak(frequencies, power []float64) float64 {
+	best := 0
+	for i := range power {
+		if power[i] > power[best] {
+			best = i
+		}
+	}
+	return frequencies[best]
+}
+
+// dft returns the discrete Fourier transform of values
+// by its definition
+func dft(values []complex128) []complex128 {
+	n := len(values)
+	result := make([]complex128, n)
+	for k := range result {
+		for t, value := range values {
+			result[k] += value * cmplx.Exp(complex(0, -2*math.Pi*float64(k*t)/float64(n)))
+		}
+	}
+	return result
+}
+
+func TestFFT(t *testing.T) {
+	// Powers of two, even, odd and prime lengths
+	for _, n := range []int{1, 2, 8, 6, 9, 13, 97} {
+		values := make([]complex128, n)
+		for i := range values {
+			values[i] = complex(math.Sin(float64(i*i)), float64(i%3))
+		}
+		expected := dft(values)
+		for i, value := range fft(values) {
+			assert.InDelta(t, real(expected[i]), real(value), 1e-9, "n=%d", n)
+			assert.InDelta(t, imag(expected[i]), imag(value), 1e-9, "n=%d", n)
+		}
+	}
+}
+
+func TestSpectrumPrimeLength(t *testing.T) {
+	// 16381 is prime and took seconds with a direct transform
+	values := sine(16381, 16)
+	start := time.Now()
+	frequencies, power, err := NewSpectrum("periodogram,v").Values(values, 1)
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) < time.Second)
+	assert.InDelta(t, 1/16.0, peak(frequencies, power), 1/16381.0)
+}
+
+func TestSpectrum(t *testing.T) {
+	values := sine(64, 8)
+	frequencies, power, err := NewSpectrum("periodogram,v").Values(values, 1)
+	assert.NoError(t, err)
+	assert.Len(t, power, 32)
+	assert.Equal(t, 1/64.0, frequencies[0])
+	assert.Equal(t, 0.125, peak(frequencies, power))
+	// The power sums to the variance of the values
+	var total float64
+	for _, p := range power {
+		total += p / 64
+	}
+	assert.InDelta(t, 0.5, total, 1e-9)
+	frequencies, power, err = NewSpectrum("welch,v,16").Values(sine(100, 4), 0.5)
+	assert.NoError(t, err)
+	assert.Len(t, power, 8)
+	assert.Equal(t, 0.5, peak(frequencies, power))
+	for _, arg := range []string{"nope,v", "welch,v,1"} {
+		_, _, err = NewSpectrum(arg).Values(values, 1)
+		assert.Error(t, err, arg)
+	}
+	_, _, err = NewSpectrum("periodogram,v").Values([]float64{1, math.NaN(), 3}, 1)
+	assert.Error(t, err)
+}
+
+func TestQuerySpectrum(t *testing.T) {
+	query := NewQuery([]string{"D0,time,value"}, "", "")
+	query.Spectrum = NewSpectrum("periodogram,value")
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{Precision: Seconds}, {}},
+		Mtx:     mtx.NewDense(16, 2, nil),
+	}
+	for i, value := range sine(16, 4) {
+		ds.Mtx.SetRow(i, []float64{float64(i * 60), value})
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"frequency", "power"}, ds.Columns)
+	frequencies, power := mtx.Col(nil, 0, ds.Mtx), mtx.Col(nil, 1, ds.Mtx)
+	assert.InDelta(t, 1/240.0, peak(frequencies, power), 1e-12)
+	// Times are not uniformly sampled
+	assert.Error(t, query.Apply(&Dataset{Columns: []string{"time", "value"}, Mtx: mtx.NewDense(3, 2, []float64{0, 1, 1, 2, 5, 3})}))
+}
@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Transform computes a new column from the
+// previous or following values of a column.
+// The result has the same number of rows and
+// is NaN where a value has no predecessor
+// (or successor for lead).
+//
+// diff      value - value n rows before
+// pct_change value / value n rows before - 1
+// cumsum    running total, NaN values are skipped
+// cumprod   running product, NaN values are skipped
+// lag       value n rows before
+// lead      value n rows after
+type Transform struct {
+	Name   string
+	Column string
+	N      int // Number of rows for diff, pct_change, lag and lead
+}
+
+// Output returns the name of the resulting column
+func (tr Transform) Output() string {
+	switch tr.Name {
+	case "cumsum", "cumprod":
+		return fmt.Sprintf("%s_%s", tr.Column, tr.Name)
+	}
+	return fmt.Sprintf("%s_%s_%d", tr.Column, tr.Name, tr.N)
+}
+
+func (tr Transform) String() string {
+	return fmt.Sprintf("%s,%s,%d", tr.Name, tr.Column, tr.N)
+}
+
+// shift returns the value n rows away from i
+// or NaN if it does not exist
+func shift(values []float64, i, n int) float64 {
+	if i+n < 0 || i+n >= len(values) {
+		return math.NaN()
+	}
+	return values[i+n]
+}
+
+// Values returns the transformed values of a column
+func (tr Transform) Values(values []float64) ([]float64, error) {
+	if tr.N < 0 {
+		return nil, fmt.Errorf("Bad transform rows: %s", tr)
+	}
+	result := make([]float64, len(values))
+	switch tr.Name {
+	case "diff":
+		for i, value := range values {
+			result[i] = value - shift(values, i, -tr.N)
+		}
+	case "pct_change":
+		for i, value := range values {
+			result[i] = value/shift(values, i, -tr.N) - 1
+		}
+	case "lag":
+		for i := range values {
+			result[i] = shift(values, i, -tr.N)
+		}
+	case "lead":
+		for i := range values {
+			result[i] = shift(values, i, tr.N)
+		}
+	case "cumsum", "cumprod":
+		total := 0.0
+		if tr.Name == "cumprod" {
+			total = 1.0
+		}
+		for i, value := range values {
+			if math.IsNaN(value) {
+				result[i] = value
+				continue
+			}
+			if tr.Name == "cumprod" {
+				total *= value
+			} else {
+				total += value
+			}
+			result[i] = total
+		}
+	default:
+		return nil, fmt.Errorf("Unknown transform: %s", tr.Name)
+	}
+	return result, nil
+}
+
+// Apply adds the transformed column to the dataset
+func (tr Transform) Apply(ds *Dataset) error {
+	column := ds.CPos(tr.Column)
+	if column < 0 {
+		return fmt.Errorf("Transform column not found: %s", tr.Column)
+	}
+	values, err := tr.Values(mtx.Col(nil, column, ds.Mtx))
+	if err != nil {
+		return err
+	}
+	meta := ds.ColumnMeta(column)
+	if tr.Name == "pct_change" || tr.Name == "cumprod" {
+		meta = Meta{} // Units no longer apply
+	}
+	ds.AddColumn(tr.Output(), values, meta)
+	return nil
+}
+
+// NewTransform returns a Transform from a string
+// parameter, N defaults to 1
+//
+// diff,value
+// lag,value,2
+// ^---^-----^---Name,Column,N
+func NewTransform(arg string) *Transform {
+	split := strings.Split(arg, ",")
+	transform := &Transform{
+		Name: strings.ToLower(split[0]),
+		N:    1,
+	}
+	if len(split) >= 2 {
+		transform.Column = split[1]
+	}
+	if len(split) >= 3 {
+		n, _ := strconv.ParseInt(split[2], 0, 64)
+		transform.N = int(n)
+	}
+	return transform
+}
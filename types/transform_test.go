@@ -0,0 +1,54 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestTransform(t *testing.T) {
+	nan := math.NaN()
+	values := []float64{1, 2, nan, 4, 8}
+	for arg, expected := range map[string][]float64{
+		"diff,v":       {nan, 1, nan, nan, 4},
+		"diff,v,2":     {nan, nan, nan, 2, nan},
+		"pct_change,v": {nan, 1, nan, nan, 1},
+		"cumsum,v":     {1, 3, nan, 7, 15},
+		"cumprod,v":    {1, 2, nan, 8, 64},
+		"lag,v,2":      {nan, nan, 1, 2, nan},
+		"lead,v":       {2, nan, 4, 8, nan},
+		"LAG,v,0":      {1, 2, nan, 4, 8},
+	} {
+		result, err := NewTransform(arg).Values(values)
+		assert.NoError(t, err, arg)
+		assert.Len(t, result, len(values))
+		for i, value := range result {
+			if math.IsNaN(expected[i]) {
+				assert.True(t, math.IsNaN(value), arg)
+			} else {
+				assert.Equal(t, expected[i], value, arg)
+			}
+		}
+	}
+	_, err := NewTransform("nope,v").Values(values)
+	assert.Error(t, err)
+	_, err = NewTransform("lag,v,-1").Values(values)
+	assert.Error(t, err)
+}
+
+func TestQueryTransform(t *testing.T) {
+	query := NewQuery([]string{"D0,value"}, "", "")
+	query.Transforms = append(query.Transforms, NewTransform("diff,value"), NewTransform("cumsum,value"))
+	ds := &Dataset{
+		Columns: []string{"value"},
+		Meta:    []Meta{{Unit: "km"}},
+		Mtx:     mtx.NewDense(3, 1, []float64{1, 3, 6}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []string{"value", "value_diff_1", "value_cumsum"}, ds.Columns)
+	assert.Equal(t, "km", ds.ColumnMeta(1).Unit)
+	assert.Equal(t, []float64{1, 4, 10}, mtx.Col(nil, 2, ds.Mtx))
+	query.Transforms = []*Transform{NewTransform("diff,nope")}
+	assert.Error(t, query.Apply(ds))
+}
@@ -15,6 +15,7 @@ var (
 	ErrNoData   = errors.New("no data")
 	ErrNotFound = errors.New("not found")
 	ErrBadQuery = errors.New("bad query")
+	ErrExists   = errors.New("already exists")
 )
 
 // Work around for handling NaN values in JSON
@@ -46,6 +47,8 @@ type Client interface {
 	Datasets() ([]*Dataset, error)
 	Write(*Dataset) error
 	Delete(string) error
+	Rename(string, string) error
+	RenameColumn(string, string, string) error
 	Query(*Query) (*Dataset, error)
 }
 
@@ -57,10 +60,12 @@ type Stats struct {
 }
 
 type dataset struct {
-	Name    string
-	Columns []string
-	Stats   *Stats
-	Mtx     []value
+	Name     string
+	Columns  []string
+	Meta     []Meta   `json:",omitempty"`
+	Warnings []string `json:",omitempty"`
+	Stats    *Stats
+	Mtx      []value
 }
 
 // Dataset consists of a name and
@@ -68,6 +73,8 @@ type dataset struct {
 type Dataset struct {
 	Name       string     // Name of this dataset
 	Columns    []string   // Ordered array of cols
+	Meta       []Meta     // Metadata for each column, may be shorter than Columns
+	Warnings   []string   // Warnings encountered while querying
 	Mtx        *mtx.Dense `json:"-"` // Dense Matrix contains all values in the dataset
 	Stats      *Stats
 	lock       sync.RWMutex
@@ -78,9 +85,11 @@ type Dataset struct {
 func (ds *Dataset) MarshalJSON() ([]byte, error) {
 	ds.stats()
 	out := &dataset{
-		Name:    ds.Name,
-		Columns: ds.Columns,
-		Stats:   ds.Stats,
+		Name:     ds.Name,
+		Columns:  ds.Columns,
+		Meta:     ds.Meta,
+		Warnings: ds.Warnings,
+		Stats:    ds.Stats,
 	}
 	if ds.WithValues && ds.Mtx != nil {
 		r, c := ds.Mtx.Dims()
@@ -99,6 +108,8 @@ func (ds *Dataset) UnmarshalJSON(data []byte) error {
 	}
 	ds.Name = in.Name
 	ds.Columns = in.Columns
+	ds.Meta = in.Meta
+	ds.Warnings = in.Warnings
 	ds.Stats = in.Stats
 	return matrix.Maybe(func() {
 		if ds.WithValues && in.Mtx != nil {
@@ -142,6 +153,47 @@ func (ds Dataset) CPos(name string) int {
 	return -1
 }
 
+// ColumnMeta returns the metadata of the
+// column at position i
+func (ds *Dataset) ColumnMeta(i int) Meta {
+	if i >= 0 && i < len(ds.Meta) {
+		return ds.Meta[i]
+	}
+	return Meta{}
+}
+
+// AddColumn appends a column of values with
+// one value for each row in the dataset
+func (ds *Dataset) AddColumn(name string, values []float64, meta Meta) {
+	r, c := 0, 0
+	if ds.Mtx != nil {
+		r, c = ds.Mtx.Dims()
+	}
+	if r == 0 {
+		r = len(values)
+	}
+	mx := mtx.NewDense(r, c+1, nil)
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			mx.Set(i, j, ds.Mtx.At(i, j))
+		}
+		if i < len(values) {
+			mx.Set(i, c, values[i])
+		} else {
+			mx.Set(i, c, math.NaN())
+		}
+	}
+	// Metadata is kept aligned with the columns
+	if len(ds.Meta) > 0 || meta != (Meta{}) {
+		for len(ds.Meta) < len(ds.Columns) {
+			ds.Meta = append(ds.Meta, Meta{})
+		}
+		ds.Meta = append(ds.Meta, meta)
+	}
+	ds.Columns = append(ds.Columns, name)
+	ds.Mtx = mx
+}
+
 // Next returns the next row of values
 // If all values have been traversed
 // it returns io.EOF. Implements the
@@ -12,6 +12,7 @@ func TestDatasetJSON(t *testing.T) {
 	ds := &Dataset{
 		Name:       "TestDataset",
 		Columns:    []string{"V1", "V2"},
+		Meta:       []Meta{{Precision: Milliseconds}},
 		Mtx:        mtx.NewDense(3, 2, []float64{1.0, 1.0, 2.0, 2.0, 3.0, math.NaN()}),
 		WithValues: true,
 	}
@@ -22,6 +23,8 @@ func TestDatasetJSON(t *testing.T) {
 	assert.Equal(t, ds.Name, out.Name)
 	assert.Equal(t, ds.Columns[0], out.Columns[0])
 	assert.Equal(t, ds.Columns[1], out.Columns[1])
+	assert.Equal(t, Milliseconds, out.ColumnMeta(0).Precision)
+	assert.Equal(t, Precision(""), out.ColumnMeta(1).Precision)
 	assert.Equal(t, 1.0, ds.Mtx.At(0, 0))
 	assert.Equal(t, 1.0, ds.Mtx.At(0, 1))
 
@@ -0,0 +1,62 @@
+package types
+
+import (
+	"fmt"
+)
+
+// Unit is a unit of measurement which can be
+// converted to any other unit of the same Kind
+type Unit struct {
+	Name   string
+	Kind   string
+	Scale  float64 // Multiplier converting to the base unit of Kind
+	Offset float64 // Added after scaling to the base unit of Kind
+}
+
+// Units contains all known units keyed by name
+var Units = map[string]Unit{
+	// Temperature (base K)
+	"K":    {Name: "K", Kind: "temperature", Scale: 1},
+	"degC": {Name: "degC", Kind: "temperature", Scale: 1, Offset: 273.15},
+	"degF": {Name: "degF", Kind: "temperature", Scale: 5.0 / 9.0, Offset: 273.15 - 32*5.0/9.0},
+	// Distance (base m)
+	"mm": {Name: "mm", Kind: "distance", Scale: 0.001},
+	"cm": {Name: "cm", Kind: "distance", Scale: 0.01},
+	"m":  {Name: "m", Kind: "distance", Scale: 1},
+	"km": {Name: "km", Kind: "distance", Scale: 1000},
+	"in": {Name: "in", Kind: "distance", Scale: 0.0254},
+	"ft": {Name: "ft", Kind: "distance", Scale: 0.3048},
+	"yd": {Name: "yd", Kind: "distance", Scale: 0.9144},
+	"mi": {Name: "mi", Kind: "distance", Scale: 1609.344},
+	// Mass (base kg)
+	"g":  {Name: "g", Kind: "mass", Scale: 0.001},
+	"kg": {Name: "kg", Kind: "mass", Scale: 1},
+	"oz": {Name: "oz", Kind: "mass", Scale: 0.028349523125},
+	"lb": {Name: "lb", Kind: "mass", Scale: 0.45359237},
+	// Duration (base s)
+	"ms":  {Name: "ms", Kind: "duration", Scale: 0.001},
+	"s":   {Name: "s", Kind: "duration", Scale: 1},
+	"min": {Name: "min", Kind: "duration", Scale: 60},
+	"h":   {Name: "h", Kind: "duration", Scale: 3600},
+	// Speed (base m/s)
+	"m/s":  {Name: "m/s", Kind: "speed", Scale: 1},
+	"km/h": {Name: "km/h", Kind: "speed", Scale: 1000.0 / 3600.0},
+	"mph":  {Name: "mph", Kind: "speed", Scale: 1609.344 / 3600.0},
+	"kn":   {Name: "kn", Kind: "speed", Scale: 1852.0 / 3600.0},
+}
+
+// GetUnit returns the Unit with the given name
+func GetUnit(name string) (Unit, error) {
+	if unit, ok := Units[name]; ok {
+		return unit, nil
+	}
+	return Unit{}, fmt.Errorf("Unknown unit: %s", name)
+}
+
+// Convert converts a value in this unit to other
+func (u Unit) Convert(value float64, other Unit) (float64, error) {
+	if u.Kind != other.Kind {
+		return 0.0, fmt.Errorf("Cannot convert %s (%s) to %s (%s)", u.Name, u.Kind, other.Name, other.Kind)
+	}
+	return (value*u.Scale + u.Offset - other.Offset) / other.Scale, nil
+}
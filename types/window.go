@@ -0,0 +1,135 @@
+package types
+
+import (
+	"fmt"
+	mtx "github.com/gonum/matrix/mat64"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window computes a Function over a rolling
+// window ending at each row of a column. The
+// window is either a number of rows or a
+// duration of the time values at Index.
+// Unlike Grouping the result has one row for
+// each input row and is added as a new column.
+type Window struct {
+	Function  *Function
+	Column    string
+	Rows      int           // Number of rows in the window
+	Duration  time.Duration // Duration of the window if Rows is 0
+	Index     int           // Index of the time column for Duration windows
+	Precision Precision     // Precision of the time values at Index
+}
+
+func (w Window) size() string {
+	if w.Rows > 0 {
+		return strconv.Itoa(w.Rows)
+	}
+	return w.Duration.String()
+}
+
+// Name returns the name of the resulting column
+func (w Window) Name() string {
+	return fmt.Sprintf("%s_%s_%s", w.Column, strings.ToLower(w.Function.Name), w.size())
+}
+
+func (w Window) String() string {
+	if w.Rows > 0 {
+		return fmt.Sprintf("%s,%s,%s", w.Function.Name, w.Column, w.size())
+	}
+	return fmt.Sprintf("%s,%s,%s,%d", w.Function.Name, w.Column, w.size(), w.Index)
+}
+
+// Views returns a view of the column for each
+// row with a complete window along with the
+// position of that row. Row windows are only
+// complete once they contain Rows values while
+// duration windows contain every previous row
+// within Duration.
+func (w Window) Views(mx *mtx.Dense, column int) ([]mtx.Matrix, []int) {
+	r, _ := mx.Dims()
+	views := make([]mtx.Matrix, 0, r)
+	rows := make([]int, 0, r)
+	start := 0
+	for i := 0; i < r; i++ {
+		if w.Rows > 0 {
+			if i+1 < w.Rows {
+				continue
+			}
+			start = i + 1 - w.Rows
+		} else {
+			current := w.Precision.Time(mx.At(i, w.Index))
+			for current.Sub(w.Precision.Time(mx.At(start, w.Index))) >= w.Duration && start < i {
+				start++
+			}
+		}
+		views = append(views, mx.View(start, column, i-start+1, 1))
+		rows = append(rows, i)
+	}
+	return views, rows
+}
+
+// Apply adds the rolling result as a new column
+// to the dataset, rows without a complete window
+// are NaN
+func (w Window) Apply(ds *Dataset) error {
+	if w.Rows <= 0 && w.Duration <= 0 {
+		return fmt.Errorf("Bad window size: %s", w)
+	}
+	switch strings.ToLower(w.Function.Name) {
+	case "min", "max", "sum", "std", "avg":
+	default:
+		return fmt.Errorf("Unknown window function: %s", w.Function.Name)
+	}
+	column := ds.CPos(w.Column)
+	if column < 0 {
+		return fmt.Errorf("Window column not found: %s", w.Column)
+	}
+	values := make([]float64, ds.Len())
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	views, rows := w.Views(ds.Mtx, column)
+	if len(views) > 0 {
+		result := w.Function.Apply(views)
+		for i, row := range rows {
+			values[row] = result.At(i, 0)
+		}
+	}
+	ds.AddColumn(w.Name(), values, ds.ColumnMeta(column))
+	return nil
+}
+
+// NewWindow returns a Window from a string
+// parameter where SIZE is either a number
+// of rows or a duration. INDEX is the time
+// column for duration windows.
+//
+// avg,value,10
+// std,value,5m,0
+// ^---^-----^--^---Function,Column,Size,Index
+func NewWindow(arg string) *Window {
+	split := strings.Split(arg, ",")
+	window := &Window{
+		Function: &Function{Name: split[0]},
+	}
+	if len(split) >= 2 {
+		window.Column = split[1]
+	}
+	if len(split) >= 3 {
+		if rows, err := strconv.ParseInt(split[2], 0, 64); err == nil {
+			window.Rows = int(rows)
+		} else {
+			duration, _ := time.ParseDuration(split[2])
+			window.Duration = duration
+		}
+	}
+	if len(split) >= 4 {
+		index, _ := strconv.ParseInt(split[3], 0, 64)
+		window.Index = int(index)
+	}
+	return window
+}
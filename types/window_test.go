@@ -0,0 +1,52 @@
+package types
+
+import (
+	mtx "github.com/gonum/matrix/mat64"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestWindow(t *testing.T) {
+	window := NewWindow("avg,value,3")
+	assert.Equal(t, 3, window.Rows)
+	assert.Equal(t, "avg,value,3", window.String())
+	assert.Equal(t, "value_avg_3", window.Name())
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Mtx:     mtx.NewDense(5, 2, []float64{0, 1, 1, 2, 2, 3, 3, 4, 10, 5}),
+	}
+	assert.NoError(t, window.Apply(ds))
+	values := mtx.Col(nil, 2, ds.Mtx)
+	assert.True(t, math.IsNaN(values[0]))
+	assert.True(t, math.IsNaN(values[1]))
+	assert.Equal(t, []float64{2, 3, 4}, values[2:])
+
+	window = NewWindow("sum,value,2s,0")
+	assert.Equal(t, 2*time.Second, window.Duration)
+	assert.Equal(t, "sum,value,2s,0", window.String())
+	assert.NoError(t, window.Apply(ds))
+	assert.Equal(t, []float64{1, 3, 5, 7, 5}, mtx.Col(nil, 3, ds.Mtx))
+
+	assert.NoError(t, NewWindow("max,value,2").Apply(ds))
+	assert.Equal(t, []float64{2, 3, 4, 5}, mtx.Col(nil, 4, ds.Mtx)[1:])
+	assert.NoError(t, NewWindow("std,value,2").Apply(ds))
+	assert.InDelta(t, math.Sqrt(0.5), ds.Mtx.At(4, 5), 1e-9)
+
+	assert.Error(t, NewWindow("avg,nope,2").Apply(ds))
+	assert.Error(t, NewWindow("avg,value").Apply(ds))
+	assert.EqualError(t, NewWindow("median,value,2").Apply(ds), "Unknown window function: median")
+}
+
+func TestQueryWindow(t *testing.T) {
+	query := NewQuery([]string{"D0,time,value"}, "", "")
+	query.Windows = append(query.Windows, NewWindow("avg,value,1s"))
+	ds := &Dataset{
+		Columns: []string{"time", "value"},
+		Meta:    []Meta{{Precision: Milliseconds}},
+		Mtx:     mtx.NewDense(3, 2, []float64{0, 1, 500, 3, 1500, 5}),
+	}
+	assert.NoError(t, query.Apply(ds))
+	assert.Equal(t, []float64{1, 2, 5}, mtx.Col(nil, 2, ds.Mtx))
+}